@@ -0,0 +1,273 @@
+package osu_parser
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	catchStarScalingFactor = 0.153
+
+	catcherBaseSize        = 106.75
+	catcherAllowedRange    = 0.8
+	catcherBaseDashSpeed   = 1.0
+	catchPlayfieldWidth    = 512.0
+	normalisedFruitRadius  = 41.0
+	playerPositioningError = 16.0
+
+	movementSkillMultiplier = 900.0
+	movementStrainDecayBase = 0.2
+	movementDecayWeight     = 0.94
+	movementSectionLength   = 750.0
+	directionChangeBonus    = 21.0
+
+	//Most fruits and droplets a single slider makes, the same as the most ticks
+	maxCatchNestedObjects = maxSliderTicks
+)
+
+type CatchDifficultyAttributes struct {
+	StarRating float64
+
+	ApproachRate float64
+	MaxCombo     int
+}
+
+// A fruit or droplet, the objects which give combo in osu!catch
+type catchObject struct {
+	time float64
+	x    float64
+
+	hyperDash bool
+	//How much further the catcher could've walked without needing a hyperdash, 0 for hyperdashes
+	distanceToHyperDash float64
+}
+
+// Width of the catcher, only the middle part of it catches fruits
+func catcherWidth(circleSize float64) float64 {
+	return catcherBaseSize * math.Abs(1-0.7*(circleSize-5)/5) * catcherAllowedRange
+}
+
+// osu!catch doesn't keep the tick distance of older maps independent of slider velocity
+func (osuFile *OsuFile) catchSliderTiming(hitObject *HitObject) SliderTiming {
+	timing := osuFile.SliderTiming(hitObject)
+
+	if osuFile.Version < 8 {
+		timing.TickDistance *= osuFile.TimingPoints.SliderVelocityAt(hitObject.Time)
+	}
+
+	return timing
+}
+
+// The fruits and droplets of a slider, at most maxCatchNestedObjects of them
+func (osuFile *OsuFile) catchNestedObjects(hitObject *HitObject) []SliderEvent {
+	timing := osuFile.catchSliderTiming(hitObject)
+	//Every span adds a repeat, ticks are already capped
+	timing.SpanCount = min(timing.SpanCount, maxCatchNestedObjects/2)
+
+	events := []SliderEvent{}
+
+	for _, event := range timing.NestedObjects() {
+		if event.Type != SliderEventLegacyLastTick && len(events) < maxCatchNestedObjects {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// Fruits and droplets in order of time, bananas and tiny droplets don't matter for difficulty
+func (osuFile *OsuFile) catchObjects() []catchObject {
+	objects := []catchObject{}
+
+	addObject := func(time float64, x float64) {
+		objects = append(objects, catchObject{
+			time: time,
+			x:    math.Max(0, math.Min(catchPlayfieldWidth, x)),
+		})
+	}
+
+	for i := range osuFile.HitObjects.List {
+		hitObject := &osuFile.HitObjects.List[i]
+
+		switch hitObject.Type {
+		case HitObjectTypeSlider:
+			for _, event := range osuFile.catchNestedObjects(hitObject) {
+				addObject(event.Time, event.Position.X)
+			}
+		case HitObjectTypeSpinner, HitObjectTypeHold:
+			continue
+		default:
+			addObject(hitObject.Time, hitObject.Position.X)
+		}
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].time < objects[j].time
+	})
+
+	return objects
+}
+
+// Marks the objects which need a hyperdash to reach the next one in time, the same way osu!stable does
+func applyHyperDashes(objects []catchObject, circleSize float64) {
+	//Hyperdashes are worked out with the whole catcher, not just the part which catches
+	halfCatcherWidth := catcherWidth(circleSize) / 2 / catcherAllowedRange
+	lastDirection := 0
+	lastExcess := halfCatcherWidth
+
+	for i := 0; i < len(objects)-1; i++ {
+		current := &objects[i]
+		next := &objects[i+1]
+
+		direction := -1
+
+		if next.x > current.x {
+			direction = 1
+		}
+
+		//Times are truncated, with a quarter of a frame of leeway
+		timeToNext := float64(int64(next.time)-int64(current.time)) - 1000.0/60/4
+		distanceToNext := math.Abs(next.x - current.x)
+
+		if lastDirection == direction {
+			distanceToNext -= lastExcess
+		} else {
+			distanceToNext -= halfCatcherWidth
+		}
+
+		distanceToHyper := timeToNext*catcherBaseDashSpeed - distanceToNext
+
+		current.hyperDash = distanceToHyper < 0
+		current.distanceToHyperDash = 0
+		lastExcess = halfCatcherWidth
+
+		if !current.hyperDash {
+			current.distanceToHyperDash = distanceToHyper
+			lastExcess = math.Max(0, math.Min(halfCatcherWidth, distanceToHyper))
+		}
+
+		lastDirection = direction
+	}
+}
+
+// Star rating and difficulty attributes for an osu!catch map, or an osu!standard map converted to osu!catch.
+// Mods changing the map are applied with ApplyMods, so a map which already had them applied should be given ModsNone
+func (osuFile *OsuFile) CatchDifficulty(mods Mods) (CatchDifficultyAttributes, error) {
+	attributes := CatchDifficultyAttributes{}
+
+	if osuFile.General.Mode != PlaymodeCatch && osuFile.General.Mode != PlaymodeOsu {
+		return attributes, ErrUnsupportedConversion
+	}
+
+	modded, err := osuFile.ApplyMods(mods)
+
+	if err != nil {
+		return attributes, err
+	}
+
+	osuFile = &modded
+
+	if err := checkHitObjectTimes(osuFile.HitObjects.List); err != nil {
+		return attributes, err
+	}
+
+	preempt := osuFile.Difficulty.Preempt()
+
+	attributes.ApproachRate = approachRateFromPreempt(preempt)
+	attributes.MaxCombo, _ = osuFile.MaxComboFor(PlaymodeCatch)
+
+	objects := osuFile.catchObjects()
+
+	if len(objects) == 0 {
+		return attributes, nil
+	}
+
+	applyHyperDashes(objects, osuFile.Difficulty.CircleSize)
+
+	//Small catchers get even smaller, nobody catches with the very edges of them
+	halfCatcherWidth := osuFile.Difficulty.CatcherWidth() / 2
+	halfCatcherWidth *= 1 - math.Max(0, osuFile.Difficulty.CircleSize-5.5)*0.0625
+
+	//Everything gets scaled so every circle size can be treated the same
+	scalingFactor := normalisedFruitRadius / halfCatcherWidth
+
+	peaks := strainPeaks{sectionLength: movementSectionLength}
+	movement := catchMovementSkill{}
+	currentStrain := 0.0
+
+	for i := 1; i < len(objects); i++ {
+		last := &objects[i-1]
+		deltaTime := objects[i].time - last.time
+
+		peaks.process(i-1, objects[i].time, func() float64 {
+			currentStrain *= strainDecay(movementStrainDecayBase, deltaTime)
+			currentStrain += movement.strainValueOf(objects[i].x*scalingFactor, last.x*scalingFactor, last, deltaTime) * movementSkillMultiplier
+
+			return currentStrain
+		}, func(time float64) float64 {
+			return currentStrain * strainDecay(movementStrainDecayBase, time-last.time)
+		})
+	}
+
+	attributes.StarRating = math.Sqrt(weightedStrainSum(peaks.all(), movementDecayWeight)) * catchStarScalingFactor
+
+	return attributes, nil
+}
+
+// Follows where the player's catcher is, moving it as little as possible
+type catchMovementSkill struct {
+	hasPosition        bool
+	lastPlayerPosition float64
+	lastDistanceMoved  float64
+	lastStrainTime     float64
+}
+
+// Positions are normalised, last is the object before the current one
+func (skill *catchMovementSkill) strainValueOf(position float64, lastPosition float64, last *catchObject, deltaTime float64) float64 {
+	//Capped to 375 bpm streams
+	strainTime := math.Max(40, deltaTime)
+
+	if !skill.hasPosition {
+		skill.lastPlayerPosition = lastPosition
+		skill.hasPosition = true
+	}
+
+	playerPosition := math.Max(position-(normalisedFruitRadius-playerPositioningError), math.Min(position+(normalisedFruitRadius-playerPositioningError), skill.lastPlayerPosition))
+	distanceMoved := playerPosition - skill.lastPlayerPosition
+
+	weightedStrainTime := strainTime + 13 + 3
+	distanceAddition := math.Pow(math.Abs(distanceMoved), 1.3) / 510
+	sqrtStrain := math.Sqrt(weightedStrainTime)
+
+	if math.Abs(distanceMoved) > 0.1 {
+		//Changing direction is harder, more so the further the catcher went the other way
+		if math.Abs(skill.lastDistanceMoved) > 0.1 && (distanceMoved > 0) != (skill.lastDistanceMoved > 0) {
+			bonusFactor := math.Min(50, math.Abs(distanceMoved)) / 50
+			antiflowFactor := math.Max(math.Min(70, math.Abs(skill.lastDistanceMoved))/70, 0.38)
+
+			distanceAddition += directionChangeBonus / math.Sqrt(skill.lastStrainTime+16) * bonusFactor * antiflowFactor * math.Max(1-math.Pow(weightedStrainTime/1000, 3), 0)
+		}
+
+		//Every movement counts a little, which gives streams some weight
+		distanceAddition += 12.5 * math.Min(math.Abs(distanceMoved), normalisedFruitRadius*2) / (normalisedFruitRadius * 6) / sqrtStrain
+	}
+
+	//Barely making it without a hyperdash is hard, after a hyperdash the catcher is always in the right place
+	if last.distanceToHyperDash <= 20 {
+		edgeDashBonus := 0.0
+
+		if last.hyperDash {
+			playerPosition = position
+		} else {
+			edgeDashBonus = 5.7
+		}
+
+		distanceAddition *= 1 + edgeDashBonus*((20-last.distanceToHyperDash)/20)*math.Pow(math.Min(strainTime, 265)/265, 1.5)
+	}
+
+	skill.lastPlayerPosition = playerPosition
+	skill.lastDistanceMoved = distanceMoved
+	skill.lastStrainTime = strainTime
+
+	return distanceAddition / weightedStrainTime
+}
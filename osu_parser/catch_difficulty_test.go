@@ -0,0 +1,95 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestCatchDifficultyConvert(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	attributes, err := parsedOsuFile.CatchDifficulty(osu_parser.ModsNone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(attributes.StarRating-2.1918) > 0.0001 || attributes.ApproachRate != 8 || attributes.MaxCombo != 88 {
+		t.Fatalf("wrong attributes: %+v", attributes)
+	}
+}
+
+func TestCatchDifficultyCatcherWidth(t *testing.T) {
+	//Fruits going back and forth across the screen, close enough in time for hyperdashes on smaller catchers
+	catchMap := func(circleSize int) string {
+		text := fmt.Sprintf("osu file format v14\n\n[General]\nMode: 2\n\n[Difficulty]\nCircleSize:%d\nApproachRate:3\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n", circleSize)
+
+		for i := 0; i < 64; i++ {
+			text += fmt.Sprintf("%d,192,%d,1,0\n", 64+(i%2)*384, i*250)
+		}
+
+		return text
+	}
+
+	large, _ := osu_parser.ParseText(catchMap(2))
+	small, _ := osu_parser.ParseText(catchMap(6))
+
+	largeAttributes, _ := large.CatchDifficulty(osu_parser.ModsNone)
+	smallAttributes, _ := small.CatchDifficulty(osu_parser.ModsNone)
+
+	if !(smallAttributes.StarRating > largeAttributes.StarRating) {
+		t.Fatalf("smaller catcher isn't harder: %f against %f", smallAttributes.StarRating, largeAttributes.StarRating)
+	}
+
+	if largeAttributes.MaxCombo != 64 || math.Abs(largeAttributes.ApproachRate-3) > 0.0001 {
+		t.Fatalf("wrong attributes: %+v", largeAttributes)
+	}
+
+	taiko, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nMode: 1\n")
+
+	if _, err := taiko.CatchDifficulty(osu_parser.ModsNone); !errors.Is(err, osu_parser.ErrUnsupportedConversion) {
+		t.Fatalf("expected ErrUnsupportedConversion, got %v", err)
+	}
+}
+
+func TestCatchDifficultyMods(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	attributes, _ := parsedOsuFile.CatchDifficulty(osu_parser.ModsNone)
+	fastAttributes, err := parsedOsuFile.CatchDifficulty(osu_parser.ModsDoubleTime)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !(fastAttributes.StarRating > attributes.StarRating) || !(fastAttributes.ApproachRate > attributes.ApproachRate) || fastAttributes.MaxCombo != attributes.MaxCombo {
+		t.Fatalf("DoubleTime didn't make the map harder: %+v against %+v", fastAttributes, attributes)
+	}
+
+	//The map given stays the same
+	if again, _ := parsedOsuFile.CatchDifficulty(osu_parser.ModsNone); again != attributes {
+		t.Fatalf("applying mods changed the map: %+v against %+v", again, attributes)
+	}
+
+	if _, err := parsedOsuFile.CatchDifficulty(osu_parser.ModsEasy | osu_parser.ModsHardRock); !errors.Is(err, osu_parser.ErrIncompatibleMods) {
+		t.Fatalf("expected ErrIncompatibleMods, got %v", err)
+	}
+}
+
+// Sliders repeating more often than any map could are cut off instead of making millions of droplets
+func TestCatchDifficultyNestedObjectLimit(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nMode: 2\n\n[Difficulty]\nSliderTickRate:8\n\n[HitObjects]\n0,192,0,2,0,L|512:192,1,400\n")
+
+	parsedOsuFile.HitObjects.List[0].RepeatCount = 1000000000
+
+	attributes, err := parsedOsuFile.CatchDifficulty(osu_parser.ModsNone)
+
+	//As many as the most ticks a slider can have
+	if err != nil || attributes.MaxCombo != 32768 {
+		t.Fatalf("expected a max combo of 32768, got %+v (%v)", attributes, err)
+	}
+}
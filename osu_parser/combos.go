@@ -0,0 +1,63 @@
+package osu_parser
+
+// Combo colours of the default skin, for maps and skins without their own
+var DefaultComboColours = []Color{
+	{R: 255, G: 192, B: 0},
+	{R: 0, G: 202, B: 0},
+	{R: 18, G: 124, B: 255},
+	{R: 242, G: 24, B: 57},
+}
+
+// Recalculates ComboIndex, IndexInCombo and ComboIndexWithOffsets of every hit object.
+// The first object and spinners always start a new combo, so does the object right after a spinner.
+// Colour skips (ComboColorOffset) only count on objects starting a combo
+func (osuFile *OsuFile) ApplyCombos() {
+	hitObjects := osuFile.HitObjects.List
+
+	for i := range hitObjects {
+		hitObject := &hitObjects[i]
+
+		if i == 0 {
+			hitObject.ComboIndex = 0
+			hitObject.IndexInCombo = 0
+			hitObject.ComboIndexWithOffsets = int32(hitObject.ComboColorOffset) + 1
+
+			continue
+		}
+
+		previous := &hitObjects[i-1]
+
+		if hitObject.NewCombo || hitObject.Type == HitObjectTypeSpinner || previous.Type == HitObjectTypeSpinner {
+			hitObject.ComboIndex = previous.ComboIndex + 1
+			hitObject.IndexInCombo = 0
+			hitObject.ComboIndexWithOffsets = previous.ComboIndexWithOffsets + int32(hitObject.ComboColorOffset) + 1
+
+			continue
+		}
+
+		hitObject.ComboIndex = previous.ComboIndex
+		hitObject.IndexInCombo = previous.IndexInCombo + 1
+		hitObject.ComboIndexWithOffsets = previous.ComboIndexWithOffsets
+	}
+}
+
+// Index into a list of colourCount combo colours.
+// Like the game, the first combo of a map uses the second colour
+func (hitObject *HitObject) ComboColourIndex(colourCount int) int {
+	if colourCount <= 0 {
+		return 0
+	}
+
+	return int(hitObject.ComboIndexWithOffsets) % colourCount
+}
+
+// Colour of the object's combo, using the map's own combo colours or the default skin's if it has none
+func (osuFile *OsuFile) ComboColour(hitObject *HitObject) Color {
+	colours := osuFile.Colours.Combos
+
+	if len(colours) == 0 {
+		colours = DefaultComboColours
+	}
+
+	return colours[hitObject.ComboColourIndex(len(colours))]
+}
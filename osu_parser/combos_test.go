@@ -0,0 +1,37 @@
+package osu_parser_test
+
+import (
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestCombos(t *testing.T) {
+	//Circle, circle, new combo skipping 2 colours, spinner, circle after the spinner
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[Colours]\nCombo1 : 1,1,1\nCombo2 : 2,2,2\nCombo3 : 3,3,3\n\n[HitObjects]\n0,0,0,1,0\n0,0,100,1,0\n0,0,200,37,0\n0,0,300,1,0\n256,192,400,8,0,500\n0,0,600,1,0\n")
+
+	expected := []struct {
+		comboIndex   int32
+		indexInCombo int32
+		colour       osu_parser.Color
+	}{
+		{0, 0, osu_parser.Color{R: 2, G: 2, B: 2}},
+		{0, 1, osu_parser.Color{R: 2, G: 2, B: 2}},
+		{1, 0, osu_parser.Color{R: 2, G: 2, B: 2}},
+		{1, 1, osu_parser.Color{R: 2, G: 2, B: 2}},
+		{2, 0, osu_parser.Color{R: 3, G: 3, B: 3}},
+		{3, 0, osu_parser.Color{R: 1, G: 1, B: 1}},
+	}
+
+	for i, want := range expected {
+		hitObject := &parsedOsuFile.HitObjects.List[i]
+
+		if hitObject.ComboIndex != want.comboIndex || hitObject.IndexInCombo != want.indexInCombo || parsedOsuFile.ComboColour(hitObject) != want.colour {
+			t.Fatalf("object %d incorrect: %+v, colour %v", i, hitObject, parsedOsuFile.ComboColour(hitObject))
+		}
+	}
+
+	if parsedOsuFile.HitObjects.List[0].ComboColourIndex(4) != 1 {
+		t.Fail()
+	}
+}
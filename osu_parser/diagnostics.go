@@ -0,0 +1,130 @@
+package osu_parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Severity int32
+
+const (
+	//The value couldn't be read and was left at its default, the rest of the line was still used
+	SeverityWarning Severity = 0
+	//The whole line couldn't be used and got skipped
+	SeverityError Severity = 1
+)
+
+var (
+	ErrInvalidVersion      = errors.New("invalid file format version")
+	ErrInvalidNumber       = errors.New("invalid number")
+	ErrIncorrectFormatting = errors.New("incorrect formatting")
+	ErrUnknownValue        = errors.New("unknown value")
+)
+
+type Diagnostic struct {
+	//Line and column of the value, both counting from 1 like editors do.
+	//Column is 0 if the problem isn't about a specific value, or the value isn't where it was written,
+	//like in storyboard lines with variables
+	Line   int
+	Column int
+
+	Section  string
+	Key      string
+	Value    string
+	Severity Severity
+
+	Err error
+}
+
+func (diagnostic Diagnostic) Error() string {
+	return fmt.Sprintf("Line %d: Error Parsing %s: %s", diagnostic.Line, diagnostic.Key, diagnostic.Err)
+}
+
+func (diagnostic Diagnostic) Unwrap() error {
+	return diagnostic.Err
+}
+
+type diagnosticCollector struct {
+	diagnostics *[]Diagnostic
+
+	//Header of the section and text of the line currently being parsed
+	section     string
+	currentLine string
+}
+
+// Line is the index of the line, counting from 0. Offset is the byte offset of the value in the line, -1 if it isn't known
+func (collector *diagnosticCollector) add(line int, key string, value string, offset int, severity Severity, err error) {
+	column := 0
+
+	if offset >= 0 && offset+len(value) <= len(collector.currentLine) {
+		column = offset + 1
+	}
+
+	*collector.diagnostics = append(*collector.diagnostics, Diagnostic{
+		Line:     line + 1,
+		Column:   column,
+		Section:  collector.section,
+		Key:      key,
+		Value:    value,
+		Severity: severity,
+		Err:      err,
+	})
+}
+
+func (collector *diagnosticCollector) warning(line int, key string, value string, offset int, err error) {
+	collector.add(line, key, value, offset, SeverityWarning, err)
+}
+
+func (collector *diagnosticCollector) error(line int, key string, value string, offset int, err error) {
+	collector.add(line, key, value, offset, SeverityError, err)
+}
+
+func (collector *diagnosticCollector) parseInt(line int, key string, value string, offset int, ret *int32) {
+	parsed, parseErr := strconv.ParseInt(value, 10, 64)
+
+	if parseErr != nil {
+		collector.warning(line, key, value, offset, fmt.Errorf("%w: %w", ErrInvalidNumber, parseErr))
+	}
+
+	*ret = int32(parsed)
+}
+
+func (collector *diagnosticCollector) parseDouble(line int, key string, value string, offset int, ret *float64) {
+	parsed, parseErr := strconv.ParseFloat(value, 64)
+
+	if parseErr != nil {
+		collector.warning(line, key, value, offset, fmt.Errorf("%w: %w", ErrInvalidNumber, parseErr))
+	}
+
+	*ret = parsed
+}
+
+// Splits text like strings.Split, also giving the byte offset every part starts at in the line.
+// Offset is where text starts in the line, offsets stay -1 if that isn't known
+func splitOffsets(text string, offset int, separator string) ([]string, []int) {
+	split := strings.Split(text, separator)
+	offsets := make([]int, len(split))
+
+	for i, part := range split {
+		offsets[i] = offset
+
+		if offset >= 0 {
+			offset += len(part) + len(separator)
+		}
+	}
+
+	return split, offsets
+}
+
+// Trims text like strings.Trim, also moving its offset in the line past what got trimmed
+func trimOffset(text string, offset int, cutset string) (string, int) {
+	trimmedLeft := strings.TrimLeft(text, cutset)
+
+	if offset >= 0 {
+		offset += len(text) - len(trimmedLeft)
+	}
+
+	return strings.TrimRight(trimmedLeft, cutset), offset
+}
@@ -0,0 +1,71 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestDiagnostics(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nAudioLeadIn: abc\n\n[TimingPoints]\n1000\n")
+
+	if len(parsedOsuFile.Diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", parsedOsuFile.Diagnostics)
+	}
+
+	numberDiagnostic := parsedOsuFile.Diagnostics[0]
+
+	if numberDiagnostic.Line != 4 || numberDiagnostic.Column != 14 || numberDiagnostic.Section != "[General]" || numberDiagnostic.Key != "AudioLeadIn" || numberDiagnostic.Value != "abc" || numberDiagnostic.Severity != osu_parser.SeverityWarning {
+		t.Fatalf("number diagnostic incorrect: %+v", numberDiagnostic)
+	}
+
+	numError := &strconv.NumError{}
+
+	if !errors.Is(numberDiagnostic, osu_parser.ErrInvalidNumber) || !errors.As(numberDiagnostic, &numError) || numError.Num != "abc" {
+		t.Fatalf("number diagnostic doesn't wrap its errors: %s", numberDiagnostic)
+	}
+
+	formattingDiagnostic := parsedOsuFile.Diagnostics[1]
+
+	if formattingDiagnostic.Line != 7 || formattingDiagnostic.Section != "[TimingPoints]" || formattingDiagnostic.Severity != osu_parser.SeverityError || !errors.Is(formattingDiagnostic, osu_parser.ErrIncorrectFormatting) {
+		t.Fatalf("formatting diagnostic incorrect: %+v", formattingDiagnostic)
+	}
+}
+
+// Values which also show up earlier on the line still point at their own field
+func TestDiagnosticsColumns(t *testing.T) {
+	osuText := "osu file format v14\n\n[Events]\nSprite,Foreground,Centre,\"a.png\",1e,1e\n F,0,1e,1e,1\n\n[HitObjects]\n1e,1e,0,1,0,0:0:0:0:\n100,100,0,2,0,B|1e:1e,1,100\n"
+
+	parsedOsuFile, _ := osu_parser.ParseText(osuText)
+
+	expected := [][2]int{
+		{4, 34},
+		{4, 37},
+		{5, 6},
+		{5, 9},
+		{8, 1},
+		{8, 4},
+		{9, 17},
+		{9, 20},
+	}
+
+	if len(parsedOsuFile.Diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %v", len(expected), parsedOsuFile.Diagnostics)
+	}
+
+	for i, diagnostic := range parsedOsuFile.Diagnostics {
+		if diagnostic.Line != expected[i][0] || diagnostic.Column != expected[i][1] || diagnostic.Value != "1e" {
+			t.Errorf("expected line %d column %d, got %+v", expected[i][0], expected[i][1], diagnostic)
+		}
+	}
+}
+
+func TestInvalidVersion(t *testing.T) {
+	_, err := osu_parser.ParseText("not an osu file\n")
+
+	if !errors.Is(err, osu_parser.ErrInvalidVersion) {
+		t.Fatalf("expected ErrInvalidVersion, got %v", err)
+	}
+}
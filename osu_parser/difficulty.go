@@ -0,0 +1,87 @@
+package osu_parser
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	//Strain is looked at in sections of this many milliseconds
+	strainSectionLength = 400.0
+	//Strain has decayed to nothing after this many sections without objects
+	maxEmptyStrainSections = 500
+)
+
+func strainDecay(base float64, milliseconds float64) float64 {
+	return math.Pow(base, milliseconds/1000)
+}
+
+// Splits the map into sections and keeps the highest strain of each one
+type strainPeaks struct {
+	//strainSectionLength if left at 0
+	sectionLength float64
+
+	peaks             []float64
+	currentPeak       float64
+	currentSectionEnd float64
+}
+
+// Adds the next object to the peaks. initialStrain gives the decayed strain at the start of a new section
+func (strain *strainPeaks) process(index int, startTime float64, strainValue func() float64, initialStrain func(time float64) float64) {
+	if strain.sectionLength == 0 {
+		strain.sectionLength = strainSectionLength
+	}
+
+	//The first object doesn't generate a strain, so the first section starts after it
+	if index == 0 {
+		strain.currentSectionEnd = math.Ceil(startTime/strain.sectionLength) * strain.sectionLength
+	}
+
+	for sections := 0; startTime > strain.currentSectionEnd; sections++ {
+		//Long breaks skip straight to the section of the next object, so the time taken doesn't grow with the gap
+		if sections == maxEmptyStrainSections {
+			strain.currentSectionEnd += (math.Ceil((startTime-strain.currentSectionEnd)/strain.sectionLength) - 1) * strain.sectionLength
+		}
+
+		strain.peaks = append(strain.peaks, strain.currentPeak)
+		strain.currentPeak = initialStrain(strain.currentSectionEnd)
+		strain.currentSectionEnd += strain.sectionLength
+	}
+
+	strain.currentPeak = math.Max(strainValue(), strain.currentPeak)
+}
+
+func (strain *strainPeaks) all() []float64 {
+	return append(append([]float64{}, strain.peaks...), strain.currentPeak)
+}
+
+// Weighted sum of the peaks from highest to lowest, every one counting decayWeight times less than the one before
+func weightedStrainSum(peaks []float64, decayWeight float64) float64 {
+	sorted := append([]float64{}, peaks...)
+
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+	difficulty := 0.0
+	weight := 1.0
+
+	for _, strain := range sorted {
+		difficulty += strain * weight
+		weight *= decayWeight
+	}
+
+	return difficulty
+}
+
+// Difficulty calculation goes through the map section by section, times have to be in the int32 range stable accepts.
+// End times are int32 already and parsing drops sliders ending outside of it
+func checkHitObjectTimes(hitObjects []HitObject) error {
+	for i := range hitObjects {
+		//NaN fails both comparisons
+		if !(hitObjects[i].Time >= math.MinInt32 && hitObjects[i].Time <= math.MaxInt32) {
+			return fmt.Errorf("%w: hit object %d has no usable time", ErrInvalidNumber, i)
+		}
+	}
+
+	return nil
+}
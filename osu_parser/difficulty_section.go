@@ -0,0 +1,140 @@
+package osu_parser
+
+import "math"
+
+const (
+	//Radius of a hit circle at a circle size of 0
+	circleBaseRadius = 64.0
+	//Pressing an osu!standard object any earlier than this doesn't do anything
+	osuMissHitWindow = 400.0
+)
+
+// Hit windows in milliseconds, either side of the object. Judgements a mode doesn't have are 0
+type HitWindows struct {
+	//osu!mania's MAX, the rainbow 300
+	Perfect float64
+	//300
+	Great float64
+	//osu!mania's 200
+	Good float64
+	//100
+	Ok float64
+	//50
+	Meh float64
+	//Hitting any earlier than this doesn't count towards the object at all
+	Miss float64
+}
+
+// Interpolates a value from the ones it has at difficulty 0, 5 and 10.
+// This is how osu! turns every difficulty setting into what happens in game, like hit windows or HP drain
+func DifficultyRange(difficulty float64, min float64, mid float64, max float64) float64 {
+	if difficulty > 5 {
+		return mid + (max-mid)*(difficulty-5)/5
+	}
+
+	if difficulty < 5 {
+		return mid - (mid-min)*(5-difficulty)/5
+	}
+
+	return mid
+}
+
+// Interpolates a value from the ones it has at HP drain rate 0, 5 and 10
+func (difficulty DifficultySection) HPDrainRange(min float64, mid float64, max float64) float64 {
+	return DifficultyRange(difficulty.HPDrainRate, min, mid, max)
+}
+
+// Time in milliseconds an object is visible for before it has to be hit
+func (difficulty DifficultySection) Preempt() float64 {
+	return preemptTime(difficulty.ApproachRate)
+}
+
+// Preempt time in real time when the map plays at the given clock rate
+func (difficulty DifficultySection) PreemptAtRate(rate float64) float64 {
+	return difficulty.Preempt() / rate
+}
+
+// Time in milliseconds an object takes to fully fade in, starting when it appears
+func (difficulty DifficultySection) FadeIn() float64 {
+	return 400 * math.Min(1, difficulty.Preempt()/450)
+}
+
+// Fade in time in real time when the map plays at the given clock rate
+func (difficulty DifficultySection) FadeInAtRate(rate float64) float64 {
+	return difficulty.FadeIn() / rate
+}
+
+// Hit windows of a mode. osu!catch doesn't have any, osu!mania gets the ones without ScoreV2
+func (difficulty DifficultySection) HitWindows(mode Playmode) HitWindows {
+	overallDifficulty := difficulty.OverallDifficulty
+
+	switch mode {
+	case PlaymodeOsu:
+		return HitWindows{
+			Great: DifficultyRange(overallDifficulty, 80, 50, 20),
+			Ok:    DifficultyRange(overallDifficulty, 140, 100, 60),
+			Meh:   DifficultyRange(overallDifficulty, 200, 150, 100),
+			Miss:  osuMissHitWindow,
+		}
+	case PlaymodeTaiko:
+		return HitWindows{
+			Great: DifficultyRange(overallDifficulty, 50, 35, 20),
+			Ok:    DifficultyRange(overallDifficulty, 120, 80, 50),
+			Miss:  DifficultyRange(overallDifficulty, 135, 95, 70),
+		}
+	case PlaymodeMania:
+		return difficulty.ManiaHitWindows(false)
+	}
+
+	return HitWindows{}
+}
+
+// Hit windows in real time when the map plays at the given clock rate.
+// osu!mania's hit windows stay the same in real time, whatever the rate
+func (difficulty DifficultySection) HitWindowsAtRate(mode Playmode, rate float64) HitWindows {
+	hitWindows := difficulty.HitWindows(mode)
+
+	if mode == PlaymodeMania {
+		return hitWindows
+	}
+
+	hitWindows.Perfect /= rate
+	hitWindows.Great /= rate
+	hitWindows.Good /= rate
+	hitWindows.Ok /= rate
+	hitWindows.Meh /= rate
+	hitWindows.Miss /= rate
+
+	return hitWindows
+}
+
+// osu!mania's hit windows. Without ScoreV2 the MAX window is always 16ms,
+// with it the MAX window gets smaller with overall difficulty like the others
+func (difficulty DifficultySection) ManiaHitWindows(scoreV2 bool) HitWindows {
+	overallDifficulty := difficulty.OverallDifficulty
+
+	hitWindows := HitWindows{
+		Perfect: 16,
+		Great:   64 - 3*overallDifficulty,
+		Good:    97 - 3*overallDifficulty,
+		Ok:      127 - 3*overallDifficulty,
+		Meh:     151 - 3*overallDifficulty,
+		Miss:    188 - 3*overallDifficulty,
+	}
+
+	if scoreV2 {
+		hitWindows.Perfect = DifficultyRange(overallDifficulty, 22.4, 19.4, 13.9)
+	}
+
+	return hitWindows
+}
+
+// Radius of osu!standard hit circles in osu!pixels
+func (difficulty DifficultySection) CircleRadius() float64 {
+	return circleBaseRadius * circleScale(difficulty.CircleSize)
+}
+
+// Width of the osu!catch catcher in osu!pixels, only counting the part which catches fruits
+func (difficulty DifficultySection) CatcherWidth() float64 {
+	return catcherWidth(difficulty.CircleSize)
+}
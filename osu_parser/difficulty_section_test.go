@@ -0,0 +1,115 @@
+package osu_parser_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestDifficultySectionValues(t *testing.T) {
+	difficulty := osu_parser.DifficultySection{
+		HPDrainRate:       6,
+		CircleSize:        4,
+		OverallDifficulty: 6,
+		ApproachRate:      9,
+	}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"preempt", difficulty.Preempt(), 600},
+		{"preempt at 1.5x", difficulty.PreemptAtRate(1.5), 400},
+		{"fade in", difficulty.FadeIn(), 400},
+		{"fade in at 0.75x", difficulty.FadeInAtRate(0.75), 533.3333},
+		{"circle radius", difficulty.CircleRadius(), 36.48},
+		{"catcher width", difficulty.CatcherWidth(), 97.356},
+		{"hp drain range", difficulty.HPDrainRange(200, 150, 100), 140},
+	}
+
+	for _, test := range tests {
+		if math.Abs(test.got-test.want) > 0.001 {
+			t.Errorf("%s: expected %.4f, got %.4f", test.name, test.want, test.got)
+		}
+	}
+
+	//Fade in gets shorter once objects appear for less than 450ms
+	difficulty.ApproachRate = 11
+
+	if fadeIn := difficulty.FadeIn(); math.Abs(fadeIn-266.6667) > 0.001 {
+		t.Errorf("expected a fade in of 266.6667, got %.4f", fadeIn)
+	}
+}
+
+func TestDifficultySectionApproachRateDefault(t *testing.T) {
+	//v7 files don't have an approach rate yet
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v7\n\n[Difficulty]\nHPDrainRate:5\nCircleSize:4\nOverallDifficulty:7\n")
+
+	if parsedOsuFile.Difficulty.ApproachRate != 7 || parsedOsuFile.Difficulty.Preempt() != 900 {
+		t.Fatalf("expected approach rate 7 from overall difficulty, got %v", parsedOsuFile.Difficulty.ApproachRate)
+	}
+
+	parsedOsuFile, _ = osu_parser.ParseText("osu file format v14\n\n[Difficulty]\nOverallDifficulty:7\nApproachRate:0\n")
+
+	if parsedOsuFile.Difficulty.ApproachRate != 0 {
+		t.Fatalf("expected the written approach rate of 0, got %v", parsedOsuFile.Difficulty.ApproachRate)
+	}
+}
+
+func TestDifficultySectionHitWindows(t *testing.T) {
+	difficulty := osu_parser.DifficultySection{OverallDifficulty: 8}
+
+	tests := []struct {
+		name string
+		got  osu_parser.HitWindows
+		want osu_parser.HitWindows
+	}{
+		{"osu", difficulty.HitWindows(osu_parser.PlaymodeOsu), osu_parser.HitWindows{Great: 32, Ok: 76, Meh: 120, Miss: 400}},
+		{"osu at 1.5x", difficulty.HitWindowsAtRate(osu_parser.PlaymodeOsu, 1.5), osu_parser.HitWindows{Great: 21.3333, Ok: 50.6667, Meh: 80, Miss: 266.6667}},
+		{"taiko", difficulty.HitWindows(osu_parser.PlaymodeTaiko), osu_parser.HitWindows{Great: 26, Ok: 62, Miss: 80}},
+		{"catch", difficulty.HitWindows(osu_parser.PlaymodeCatch), osu_parser.HitWindows{}},
+		{"mania", difficulty.HitWindows(osu_parser.PlaymodeMania), osu_parser.HitWindows{Perfect: 16, Great: 40, Good: 73, Ok: 103, Meh: 127, Miss: 164}},
+		{"mania at 1.5x", difficulty.HitWindowsAtRate(osu_parser.PlaymodeMania, 1.5), osu_parser.HitWindows{Perfect: 16, Great: 40, Good: 73, Ok: 103, Meh: 127, Miss: 164}},
+		{"mania v2", difficulty.ManiaHitWindows(true), osu_parser.HitWindows{Perfect: 16.1, Great: 40, Good: 73, Ok: 103, Meh: 127, Miss: 164}},
+	}
+
+	for _, test := range tests {
+		got := []float64{test.got.Perfect, test.got.Great, test.got.Good, test.got.Ok, test.got.Meh, test.got.Miss}
+		want := []float64{test.want.Perfect, test.want.Great, test.want.Good, test.want.Ok, test.want.Meh, test.want.Miss}
+
+		for i := range got {
+			if math.Abs(got[i]-want[i]) > 0.001 {
+				t.Errorf("%s: expected %+v, got %+v", test.name, test.want, test.got)
+				break
+			}
+		}
+	}
+}
+
+// A map with DoubleTime applied plays the same as the rate adjusted values of the original
+func TestDifficultySectionMatchesApplyMods(t *testing.T) {
+	osuFile, err := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doubleTime, err := osuFile.ApplyMods(osu_parser.ModsDoubleTime)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := doubleTime.Difficulty.Preempt(), osuFile.Difficulty.PreemptAtRate(1.5); math.Abs(got-want) > 0.001 {
+		t.Errorf("expected a preempt of %.4f, got %.4f", want, got)
+	}
+
+	got := doubleTime.Difficulty.HitWindows(osu_parser.PlaymodeOsu).Great
+	want := osuFile.Difficulty.HitWindowsAtRate(osu_parser.PlaymodeOsu, 1.5).Great
+
+	if math.Abs(got-want) > 0.001 {
+		t.Errorf("expected a 300 hit window of %.4f, got %.4f", want, got)
+	}
+}
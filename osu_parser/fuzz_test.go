@@ -0,0 +1,57 @@
+package osu_parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func FuzzParseText(f *testing.F) {
+	cases, _ := filepath.Glob("../cases/*.osu")
+
+	for _, filename := range cases {
+		data, err := os.ReadFile(filename)
+
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		f.Add(string(data))
+	}
+
+	f.Add(storyboardTestText)
+	f.Add("osu file format v14\n[HitObjects]\n256,192\n")
+	f.Add("osu file format v14\n[HitObjects]\n256,192,1000,1,0,1\n")
+	f.Add("osu file format v14\n[HitObjects]\n256,192,1000,2,0\n")
+	f.Add("osu file format v14\n[HitObjects]\n256,192,1000,2,0,B|1:1,-3,10,2|2\n")
+	f.Add("osu file format v14\n[HitObjects]\n256,192,1000,8,0\n")
+	f.Add("osu file format v14\n[HitObjects]\n256,192,1000,128,0,2000\n")
+	f.Add("osu file format v14\n[Events]\n2,1000\n Sprite\n")
+
+	f.Fuzz(func(t *testing.T, osuText string) {
+		parsedOsuFile, err := osu_parser.ParseText(osuText)
+
+		if err != nil {
+			return
+		}
+
+		parsedOsuFile.Encode()
+
+		for j := range parsedOsuFile.HitObjects.List {
+			parsedOsuFile.HitObjects.List[j].Path().EndPosition()
+		}
+
+		//Anything that parses has to come back out of lossless mode unchanged
+		losslessOsuFile, err := osu_parser.ParseTextLossless(osuText)
+
+		if err != nil {
+			t.Fatalf("lossless parse failed where regular parse didn't: %s", err)
+		}
+
+		if losslessOsuFile.Encode() != osuText {
+			t.Fatalf("lossless encoding changed the file")
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package osu_parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	//Objects slightly before a timing point still use it
+	sampleTimingLeniency = 1.0
+)
+
+var additionSounds = []struct {
+	sound HitSoundType
+	name  string
+}{
+	{HitSoundTypeWhistle, "whistle"},
+	{HitSoundTypeFinish, "finish"},
+	{HitSoundTypeClap, "clap"},
+}
+
+// One sample the game plays, either from the skin or the beatmap folder
+type HitSample struct {
+	Filename string
+
+	//HitSoundTypeNone for the normal hit sound, which always plays
+	Sound     HitSoundType
+	SampleSet SampleSet
+	//0 means the skin's samples, 1 and up the beatmap's own
+	CustomSampleSet CustomSampleSet
+	Volume          int32
+}
+
+// The samples played at one point in time, for a whole object or one node of a slider
+type HitSoundEvent struct {
+	HitObjectIndex int
+	//Slider node, counting from 0 at the head. Always 0 for anything but sliders
+	NodeIndex int
+	Time      float64
+
+	Samples []HitSample
+}
+
+// Works out which samples every hit object and slider node plays, with the same fallbacks as the game:
+// unset sample sets come from the timing point, then from General.SampleSet,
+// unset additions use the normal sample set, unset volumes and custom sets come from the timing point
+func (osuFile *OsuFile) HitSounds() []HitSoundEvent {
+	events := []HitSoundEvent{}
+
+	for i := range osuFile.HitObjects.List {
+		hitObject := &osuFile.HitObjects.List[i]
+
+		switch hitObject.Type {
+		case HitObjectTypeSlider:
+			timing := osuFile.SliderTiming(hitObject)
+
+			for node := 0; node <= int(timing.SpanCount); node++ {
+				sound := hitObject.HitSound
+				sampleSet := hitObject.SampleSet
+				sampleSetAddition := hitObject.SampleSetAddition
+
+				if node < len(hitObject.SoundTypes) {
+					sound = hitObject.SoundTypes[node]
+				}
+
+				if node < len(hitObject.SampleSets) {
+					sampleSet = hitObject.SampleSets[node]
+					sampleSetAddition = SampleSetNone
+
+					if node < len(hitObject.SampleSetAdditions) {
+						sampleSetAddition = hitObject.SampleSetAdditions[node]
+					}
+				}
+
+				time := timing.StartTime + float64(node)*timing.SpanDuration
+
+				events = append(events, HitSoundEvent{
+					HitObjectIndex: i,
+					NodeIndex:      node,
+					Time:           time,
+					Samples:        osuFile.resolveSamples(hitObject, time, sound, sampleSet, sampleSetAddition),
+				})
+			}
+		default:
+			time := hitObject.Time
+
+			//Spinners make their sound once they're done
+			if hitObject.Type == HitObjectTypeSpinner {
+				time = float64(hitObject.EndTime)
+			}
+
+			events = append(events, HitSoundEvent{
+				HitObjectIndex: i,
+				Time:           time,
+				Samples:        osuFile.resolveSamples(hitObject, time, hitObject.HitSound, hitObject.SampleSet, hitObject.SampleSetAddition),
+			})
+		}
+	}
+
+	return events
+}
+
+func (osuFile *OsuFile) resolveSamples(hitObject *HitObject, time float64, sound HitSoundType, sampleSet SampleSet, sampleSetAddition SampleSet) []HitSample {
+	controlPoint := osuFile.TimingPoints.ControlPointAt(time + sampleTimingLeniency)
+
+	for _, fallback := range []SampleSet{controlPoint.SampleSet, osuFile.General.SampleSet, SampleSetNormal} {
+		if _, ok := sampleSetNames[sampleSet]; ok {
+			break
+		}
+
+		sampleSet = fallback
+	}
+
+	if _, ok := sampleSetNames[sampleSetAddition]; !ok {
+		sampleSetAddition = sampleSet
+	}
+
+	customSampleSet := hitObject.CustomSampleSet
+
+	if customSampleSet == CustomSampleSetNone {
+		customSampleSet = controlPoint.CustomSampleSet
+	}
+
+	volume := hitObject.Volume
+
+	if volume <= 0 {
+		volume = controlPoint.Volume
+	}
+
+	newSample := func(sound HitSoundType, name string, sampleSet SampleSet) HitSample {
+		return HitSample{
+			Filename:        sampleFilename(name, sampleSet, customSampleSet),
+			Sound:           sound,
+			SampleSet:       sampleSet,
+			CustomSampleSet: customSampleSet,
+			Volume:          volume,
+		}
+	}
+
+	normal := newSample(HitSoundTypeNone, "normal", sampleSet)
+
+	//A custom file replaces every sample of the object, additions don't play with it
+	if len(hitObject.SampleFile) != 0 {
+		normal.Filename = hitObject.SampleFile
+
+		return []HitSample{normal}
+	}
+
+	samples := []HitSample{normal}
+
+	for _, addition := range additionSounds {
+		if sound&addition.sound != 0 {
+			samples = append(samples, newSample(addition.sound, addition.name, sampleSetAddition))
+		}
+	}
+
+	return samples
+}
+
+// Custom sample sets 0 and 1 share their file names, 0 just loads them from the skin instead
+func sampleFilename(name string, sampleSet SampleSet, customSampleSet CustomSampleSet) string {
+	if customSampleSet > CustomSampleSet1 {
+		return fmt.Sprintf("%s-hit%s%d.wav", strings.ToLower(sampleSetNames[sampleSet]), name, customSampleSet)
+	}
+
+	return fmt.Sprintf("%s-hit%s.wav", strings.ToLower(sampleSetNames[sampleSet]), name)
+}
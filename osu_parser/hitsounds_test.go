@@ -0,0 +1,95 @@
+package osu_parser_test
+
+import (
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+const hitSoundsTestText = `osu file format v14
+
+[General]
+SampleSet: Soft
+
+[Difficulty]
+SliderMultiplier:1
+SliderTickRate:1
+
+[TimingPoints]
+0,500,4,0,0,40,1,0
+1000,500,4,3,2,60,1,0
+
+[HitObjects]
+0,0,0,1,8,0:0:0:0:
+0,0,500,1,2,1:2:0:80:custom.wav
+0,0,1000,1,4,0:0:0:0:
+0,0,1000,2,0,L|100:0,1,100,2|8,0:0|1:3,0:0:0:0:
+`
+
+func filenames(samples []osu_parser.HitSample) []string {
+	names := []string{}
+
+	for _, sample := range samples {
+		names = append(names, sample.Filename)
+	}
+
+	return names
+}
+
+func TestHitSounds(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(hitSoundsTestText)
+
+	events := parsedOsuFile.HitSounds()
+
+	expected := []struct {
+		time      float64
+		filenames []string
+		volume    int32
+	}{
+		//Sample set from General as the timing point doesn't set one
+		{0, []string{"soft-hitnormal.wav", "soft-hitclap.wav"}, 40},
+		//Custom file replaces the whole hit sound including the whistle, the object's own volume wins
+		{500, []string{"custom.wav"}, 80},
+		//Custom sample set from the timing point
+		{1000, []string{"drum-hitnormal2.wav", "drum-hitfinish2.wav"}, 60},
+		//Slider head and tail with their own sounds and sample sets
+		{1000, []string{"drum-hitnormal2.wav", "drum-hitwhistle2.wav"}, 60},
+		{1500, []string{"normal-hitnormal2.wav", "drum-hitclap2.wav"}, 60},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+
+	for i, event := range events {
+		got := filenames(event.Samples)
+
+		if event.Time != expected[i].time || len(got) != len(expected[i].filenames) || event.Samples[0].Volume != expected[i].volume {
+			t.Fatalf("event %d incorrect: %+v", i, event)
+		}
+
+		for j := range got {
+			if got[j] != expected[i].filenames[j] {
+				t.Fatalf("event %d: expected %v, got %v", i, expected[i].filenames, got)
+			}
+		}
+	}
+
+	if events[4].HitObjectIndex != 3 || events[4].NodeIndex != 1 {
+		t.Fatalf("slider tail event incorrect: %+v", events[4])
+	}
+}
+
+func TestHitSoundsSampleFileOnly(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[HitObjects]\n0,0,0,1,14,3:2:0:0:custom.wav\n")
+
+	events := parsedOsuFile.HitSounds()
+
+	if len(events) != 1 || len(events[0].Samples) != 1 {
+		t.Fatalf("expected a single sample, got %+v", events)
+	}
+
+	if sample := events[0].Samples[0]; sample.Filename != "custom.wav" || sample.Sound != osu_parser.HitSoundTypeNone {
+		t.Fatalf("custom sample incorrect: %+v", sample)
+	}
+}
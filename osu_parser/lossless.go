@@ -0,0 +1,615 @@
+package osu_parser
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Keeps track of which source line every parsed value came from
+type sourceMap struct {
+	keys             map[int]string
+	timingPointLines map[int]int
+	hitObjectLines   map[int]int
+	eventLines       map[int]int
+	storyboardLines  map[int]bool
+
+	timingPointCount int
+	hitObjectCount   int
+	eventCount       int
+}
+
+func newSourceMap() *sourceMap {
+	return &sourceMap{
+		keys:             map[int]string{},
+		timingPointLines: map[int]int{},
+		hitObjectLines:   map[int]int{},
+		eventLines:       map[int]int{},
+		storyboardLines:  map[int]bool{},
+	}
+}
+
+func (sourceMap *sourceMap) recordLine(line int, key string, osuFile *OsuFile) {
+	sourceMap.keys[line] = key
+	sourceMap.timingPointCount = len(osuFile.TimingPoints.TimingPoints)
+	sourceMap.hitObjectCount = len(osuFile.HitObjects.List)
+	sourceMap.eventCount = len(osuFile.Events.Events)
+}
+
+func (sourceMap *sourceMap) recordItems(line int, osuFile *OsuFile) {
+	if len(osuFile.TimingPoints.TimingPoints) > sourceMap.timingPointCount {
+		sourceMap.timingPointLines[line] = len(osuFile.TimingPoints.TimingPoints) - 1
+	}
+
+	if len(osuFile.HitObjects.List) > sourceMap.hitObjectCount {
+		sourceMap.hitObjectLines[line] = len(osuFile.HitObjects.List) - 1
+	}
+
+	if len(osuFile.Events.Events) > sourceMap.eventCount {
+		sourceMap.eventLines[line] = len(osuFile.Events.Events) - 1
+	}
+}
+
+func (sourceMap *sourceMap) recordStoryboardLine(line int) {
+	sourceMap.storyboardLines[line] = true
+}
+
+type sourceLine struct {
+	//Line content without the line ending
+	text   string
+	ending string
+
+	section Section
+	//Whether the line holds something the parser reads, as opposed to comments, blank lines and headers
+	content bool
+	key     string
+	//Index into the timing point, hit object or event list, -1 if the line isn't one
+	index int
+	//Storyboard objects and commands in [Events]
+	storyboard bool
+}
+
+// An OsuFile which remembers the exact text it was parsed from.
+// Encoding an unmodified LosslessOsuFile gives back the original text byte for byte,
+// after modifications only the lines of changed values get rewritten.
+type LosslessOsuFile struct {
+	OsuFile
+
+	original losslessSnapshot
+	source   []sourceLine
+}
+
+// Everything Encode compares, encoded the way it would be written
+type losslessSnapshot struct {
+	values       map[Section]map[string]string
+	timingPoints []string
+	hitObjects   []string
+	events       []string
+	storyboard   []string
+}
+
+func takeSnapshot(osuFile *OsuFile) losslessSnapshot {
+	snapshot := losslessSnapshot{
+		values:       map[Section]map[string]string{},
+		timingPoints: encodeItems(osuFile.TimingPoints.TimingPoints, encodeTimingPoint),
+		hitObjects:   encodeItems(osuFile.HitObjects.List, encodeHitObject),
+		events:       encodeItems(osuFile.Events.Events, encodeEvent),
+		storyboard:   encodeStoryboard(osuFile.Events.Storyboard),
+	}
+
+	for _, section := range keyValueSectionOrder {
+		snapshot.values[section] = toMap(keyValueSectionValues(osuFile, section))
+	}
+
+	return snapshot
+}
+
+func ParseFileLossless(filename string) (*LosslessOsuFile, error) {
+	data, err := os.ReadFile(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseTextLossless(string(data))
+}
+
+func ParseTextLossless(osuText string) (*LosslessOsuFile, error) {
+	sourceMap := newSourceMap()
+
+	parsedOsuFile, err := parseReader(strings.NewReader(osuText), ParseOptions{}, sourceMap)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//Encoded up front, so changes made through shared slices still show up as changes
+	returnOsuFile := &LosslessOsuFile{
+		OsuFile:  parsedOsuFile,
+		original: takeSnapshot(&parsedOsuFile),
+	}
+
+	rawLines := strings.Split(osuText, "\n")
+	currentSection := SectionGeneral
+
+	for i, rawLine := range rawLines {
+		line := sourceLine{
+			text:    rawLine,
+			section: currentSection,
+			index:   -1,
+		}
+
+		if i != len(rawLines)-1 {
+			line.ending = "\n"
+		}
+
+		if strings.HasSuffix(line.text, "\r") {
+			line.text = strings.TrimSuffix(line.text, "\r")
+			line.ending = "\r" + line.ending
+		}
+
+		trimmed := strings.Trim(cleanLine(line.text), "\t ")
+
+		if section, ok := sectionHeaders[trimmed]; ok {
+			currentSection = section
+			line.section = section
+		}
+
+		if key, ok := sourceMap.keys[i]; ok {
+			line.content = true
+			line.key = key
+		}
+
+		if index, ok := sourceMap.timingPointLines[i]; ok {
+			line.index = index
+		}
+
+		if index, ok := sourceMap.hitObjectLines[i]; ok {
+			line.index = index
+		}
+
+		if index, ok := sourceMap.eventLines[i]; ok {
+			line.index = index
+		}
+
+		line.storyboard = sourceMap.storyboardLines[i]
+
+		returnOsuFile.source = append(returnOsuFile.source, line)
+	}
+
+	return returnOsuFile, nil
+}
+
+func (osuFile *LosslessOsuFile) newLine() string {
+	if len(osuFile.source) != 0 && len(osuFile.source[0].ending) != 0 {
+		return osuFile.source[0].ending
+	}
+
+	return "\r\n"
+}
+
+func keyValueSectionValues(osuFile *OsuFile, section Section) []keyValue {
+	switch section {
+	case SectionGeneral:
+		return osuFile.generalValues()
+	case SectionEditor:
+		return osuFile.editorValues()
+	case SectionMetadata:
+		return osuFile.metadataValues()
+	case SectionDifficulty:
+		return osuFile.difficultyValues()
+	case SectionColours:
+		return osuFile.colourValues()
+	}
+
+	return nil
+}
+
+var keyValueSectionSeparators = map[Section]string{
+	SectionGeneral:    ": ",
+	SectionEditor:     ": ",
+	SectionMetadata:   ":",
+	SectionDifficulty: ":",
+	SectionColours:    " : ",
+}
+
+var keyValueSectionOrder = []Section{
+	SectionGeneral,
+	SectionEditor,
+	SectionMetadata,
+	SectionDifficulty,
+	SectionColours,
+}
+
+var sectionNames = map[Section]string{}
+
+func init() {
+	for header, section := range sectionHeaders {
+		sectionNames[section] = header
+	}
+}
+
+func toMap(values []keyValue) map[string]string {
+	valueMap := map[string]string{}
+
+	for _, pair := range values {
+		valueMap[pair.key] = pair.value
+	}
+
+	return valueMap
+}
+
+// Rewrites the value of a key/value line, keeping the key and separator exactly as written
+func replaceValue(text string, value string) string {
+	separator := strings.Index(text, ":")
+
+	if separator == -1 {
+		return text
+	}
+
+	separator++
+
+	for separator < len(text) && text[separator] == ' ' {
+		separator++
+	}
+
+	return text[:separator] + value
+}
+
+func (osuFile *LosslessOsuFile) Encode() string {
+	newLine := osuFile.newLine()
+	builder := strings.Builder{}
+
+	current := takeSnapshot(&osuFile.OsuFile)
+
+	currentValues := current.values
+	originalValues := osuFile.original.values
+
+	//Keys which have a line in the source
+	writtenKeys := map[Section]map[string]bool{}
+	//Index of the last line holding content of every section, used for appending
+	lastContentLine := map[Section]int{}
+	presentSections := map[Section]bool{}
+
+	for i, line := range osuFile.source {
+		presentSections[line.section] = true
+
+		if line.content || lastContentLine[line.section] == 0 {
+			lastContentLine[line.section] = i
+		}
+
+		if line.content {
+			if writtenKeys[line.section] == nil {
+				writtenKeys[line.section] = map[string]bool{}
+			}
+
+			writtenKeys[line.section][line.key] = true
+		}
+	}
+
+	//Values which weren't in the source to begin with get appended to the end of their section
+	appendedValues := map[Section][]string{}
+
+	for _, section := range keyValueSectionOrder {
+		for _, pair := range keyValueSectionValues(&osuFile.OsuFile, section) {
+			original, hadOriginal := originalValues[section][pair.key]
+
+			if writtenKeys[section][pair.key] || (hadOriginal && original == pair.value) {
+				continue
+			}
+
+			appendedValues[section] = append(appendedValues[section], pair.key+keyValueSectionSeparators[section]+pair.value)
+		}
+	}
+
+	//Timing points, hit objects and events are matched against the ones they were parsed as,
+	//so only the lines of the ones which changed get rewritten, new ones go where they were inserted
+	listEdits := map[Section]listEdit{
+		SectionTimingPoints: diffItems(osuFile.original.timingPoints, current.timingPoints),
+		SectionHitObjects:   diffItems(osuFile.original.hitObjects, current.hitObjects),
+		SectionEvents:       diffItems(osuFile.original.events, current.events),
+	}
+
+	//Ones added after the last existing one get appended to the end of their section
+	for section, edit := range listEdits {
+		appendedValues[section] = append(appendedValues[section], edit.inserted[len(edit.kept)]...)
+	}
+
+	//The storyboard is only rewritten as a whole, in place of the old one
+	currentStoryboard := current.storyboard
+	storyboardChanged := strings.Join(currentStoryboard, "\n") != strings.Join(osuFile.original.storyboard, "\n")
+	storyboardComments := map[string]bool{}
+	storyboardWritten := false
+
+	for _, storyboardLine := range currentStoryboard {
+		if strings.HasPrefix(storyboardLine, "//") {
+			storyboardComments[storyboardLine] = true
+		}
+	}
+
+	if storyboardChanged {
+		storyboardWritten = true
+
+		for _, line := range osuFile.source {
+			if line.section == SectionEvents && (line.storyboard || storyboardComments[strings.Trim(cleanLine(line.text), "\t ")]) {
+				storyboardWritten = false
+				break
+			}
+		}
+
+		if storyboardWritten {
+			appendedValues[SectionEvents] = append(appendedValues[SectionEvents], currentStoryboard...)
+		}
+	}
+
+	//Sections which don't exist in the source at all get added before [HitObjects]
+	writeMissingSections := func() {
+		for _, section := range []Section{SectionGeneral, SectionEditor, SectionMetadata, SectionDifficulty, SectionEvents, SectionTimingPoints, SectionColours} {
+			if presentSections[section] || len(appendedValues[section]) == 0 {
+				continue
+			}
+
+			builder.WriteString(sectionNames[section] + newLine)
+
+			for _, appended := range appendedValues[section] {
+				builder.WriteString(appended + newLine)
+			}
+
+			builder.WriteString(newLine)
+		}
+	}
+
+	for i, line := range osuFile.source {
+		text := line.text
+		trimmed := strings.Trim(cleanLine(text), "\t ")
+		keep := true
+
+		if trimmed == sectionNames[SectionHitObjects] {
+			writeMissingSections()
+		}
+
+		switch {
+		case line.section == SectionEvents && storyboardChanged && (line.storyboard || storyboardComments[trimmed]):
+			if !storyboardWritten {
+				for _, storyboardLine := range currentStoryboard {
+					builder.WriteString(storyboardLine + newLine)
+				}
+
+				storyboardWritten = true
+			}
+
+			keep = false
+		case line.index != -1:
+			edit := listEdits[line.section]
+
+			for _, inserted := range edit.inserted[line.index] {
+				builder.WriteString(inserted + newLine)
+			}
+
+			keep = edit.kept[line.index]
+		case line.content && currentValues[line.section] != nil:
+			current, hasCurrent := currentValues[line.section][line.key]
+			original, hadOriginal := originalValues[line.section][line.key]
+
+			if hadOriginal && !hasCurrent {
+				keep = false
+			} else if hasCurrent && (!hadOriginal || current != original) {
+				text = replaceValue(text, current)
+			}
+		}
+
+		if keep {
+			builder.WriteString(text + line.ending)
+		}
+
+		if i == lastContentLine[line.section] && len(appendedValues[line.section]) != 0 && presentSections[line.section] {
+			//The last line of a file might not have a line ending yet
+			if len(line.ending) == 0 {
+				builder.WriteString(newLine)
+			}
+
+			for _, appended := range appendedValues[line.section] {
+				builder.WriteString(appended + newLine)
+			}
+		}
+	}
+
+	if !presentSections[SectionHitObjects] {
+		writeMissingSections()
+	}
+
+	return builder.String()
+}
+
+func encodeItems[T any](items []T, encode func(T) string) []string {
+	encoded := make([]string, len(items))
+
+	for i, item := range items {
+		encoded[i] = encode(item)
+	}
+
+	return encoded
+}
+
+// How a list of encoded items turned into another
+type listEdit struct {
+	//Whether the original item at an index is still there unchanged
+	kept []bool
+	//Items which get written before the original item at an index, the ones after the last original item are at len(kept)
+	inserted [][]string
+}
+
+// Past this many edits lists are matched up by position, which keeps diffing large lists cheap
+const maxDiffEdits = 1024
+
+// Finds the fewest insertions and deletions which turn original into current
+func diffItems(original []string, current []string) listEdit {
+	edit := listEdit{
+		kept:     make([]bool, len(original)),
+		inserted: make([][]string, len(original)+1),
+	}
+
+	prefix := 0
+
+	for prefix < len(original) && prefix < len(current) && original[prefix] == current[prefix] {
+		edit.kept[prefix] = true
+		prefix++
+	}
+
+	suffix := 0
+
+	for suffix < len(original)-prefix && suffix < len(current)-prefix && original[len(original)-1-suffix] == current[len(current)-1-suffix] {
+		edit.kept[len(original)-1-suffix] = true
+		suffix++
+	}
+
+	changedOriginal := original[prefix : len(original)-suffix]
+	changedCurrent := current[prefix : len(current)-suffix]
+
+	matches := matchItems(changedOriginal, changedCurrent)
+	//Matching the ends of both lists makes everything after the last real match get inserted
+	matches = append(matches, [2]int{len(changedOriginal), len(changedCurrent)})
+
+	originalIndex := 0
+	currentIndex := 0
+
+	for _, match := range matches {
+		//Replacements stay where the item they replace was
+		insertAt := prefix + originalIndex
+
+		edit.inserted[insertAt] = append(edit.inserted[insertAt], changedCurrent[currentIndex:match[1]]...)
+
+		if match[0] < len(changedOriginal) {
+			edit.kept[prefix+match[0]] = true
+		}
+
+		originalIndex = match[0] + 1
+		currentIndex = match[1] + 1
+	}
+
+	return edit
+}
+
+// Indexes of equal items in both lists, in order, using Myers' diff algorithm
+func matchItems(original []string, current []string) [][2]int {
+	n := len(original)
+	m := len(current)
+
+	if n == 0 || m == 0 {
+		return nil
+	}
+
+	offset := n + m
+	furthest := make([]int, 2*offset+2)
+	//Furthest reaching x of every diagonal after every round, only the diagonals the round could reach
+	trace := [][]int{}
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return matchByPosition(original, current)
+		}
+
+		done := false
+
+		for k := -d; k <= d && !done; k += 2 {
+			x := 0
+
+			if k == -d || (k != d && furthest[offset+k-1] < furthest[offset+k+1]) {
+				x = furthest[offset+k+1]
+			} else {
+				x = furthest[offset+k-1] + 1
+			}
+
+			y := x - k
+
+			for x < n && y < m && original[x] == current[y] {
+				x++
+				y++
+			}
+
+			furthest[offset+k] = x
+			done = x >= n && y >= m
+		}
+
+		trace = append(trace, append([]int{}, furthest[offset-d:offset+d+1]...))
+
+		if done {
+			return backtrackMatches(original, current, trace)
+		}
+	}
+
+	return nil
+}
+
+func backtrackMatches(original []string, current []string, trace [][]int) [][2]int {
+	matches := [][2]int{}
+
+	x := len(original)
+	y := len(current)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		previous := trace[d-1]
+		//The previous round reached diagonals -(d-1) to d-1
+		previousAt := func(k int) int {
+			return previous[k+d-1]
+		}
+
+		k := x - y
+		startX := 0
+		startY := 0
+
+		if k == -d || (k != d && previousAt(k-1) < previousAt(k+1)) {
+			//Came down from diagonal k+1, an insertion
+			startX = previousAt(k + 1)
+			startY = startX - k
+		} else {
+			//Came right from diagonal k-1, a deletion
+			startX = previousAt(k-1) + 1
+			startY = startX - k
+		}
+
+		for x > startX && y > startY {
+			x--
+			y--
+			matches = append(matches, [2]int{x, y})
+		}
+
+		if k == -d || (k != d && previousAt(k-1) < previousAt(k+1)) {
+			x = startX
+			y = startY - 1
+		} else {
+			x = startX - 1
+			y = startY
+		}
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+		matches = append(matches, [2]int{x, y})
+	}
+
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+
+	return matches
+}
+
+func matchByPosition(original []string, current []string) [][2]int {
+	matches := [][2]int{}
+
+	for i := 0; i < len(original) && i < len(current); i++ {
+		if original[i] == current[i] {
+			matches = append(matches, [2]int{i, i})
+		}
+	}
+
+	return matches
+}
+
+func (osuFile *LosslessOsuFile) WriteTo(writer io.Writer) (int64, error) {
+	written, err := io.WriteString(writer, osuFile.Encode())
+
+	return int64(written), err
+}
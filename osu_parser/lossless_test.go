@@ -0,0 +1,194 @@
+package osu_parser_test
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestLosslessUnmodified(t *testing.T) {
+	cases, _ := filepath.Glob("../cases/*.osu")
+
+	for _, filename := range cases {
+		data, _ := os.ReadFile(filename)
+
+		parsedOsuFile, err := osu_parser.ParseTextLossless(string(data))
+
+		if err != nil {
+			t.Fatalf("%s: %s", filename, err)
+		}
+
+		encoded := parsedOsuFile.Encode()
+
+		if encoded != string(data) {
+			t.Fatalf("%s: unmodified file didn't encode byte for byte", filename)
+		}
+
+		reparsedOsuFile, _ := osu_parser.ParseText(encoded)
+
+		if reparsedOsuFile.Md5Hash != parsedOsuFile.Md5Hash {
+			t.Fatalf("%s: hash changed", filename)
+		}
+	}
+}
+
+func TestLosslessModified(t *testing.T) {
+	original := "osu file format v14\r\n\r\n[General]\r\nAudioFilename: audio.mp3\r\nSpecialStyle: 0\r\n\r\n[Metadata]\r\n//keep me\r\nTitle:Old\r\nCreator:Someone\r\n\r\n[TimingPoints]\r\n0,500,4,2,0,100,1,0\r\n1000,-50,4,2,0,80,0,1\r\n\r\n[HitObjects]\r\n256,192,0,1,0,0:0:0:0:\r\n256,192,500,1,2,0:0:0:0:\r\n"
+
+	parsedOsuFile, _ := osu_parser.ParseTextLossless(original)
+
+	parsedOsuFile.Metadata.Title = "New"
+	parsedOsuFile.General.AudioHash = "abc"
+	parsedOsuFile.TimingPoints.TimingPoints[1].Volume = 60
+	parsedOsuFile.HitObjects.List = parsedOsuFile.HitObjects.List[:1]
+	parsedOsuFile.HitObjects.List[0].HitSound = osu_parser.HitSoundTypeClap
+
+	expected := "osu file format v14\r\n\r\n[General]\r\nAudioFilename: audio.mp3\r\nSpecialStyle: 0\r\nAudioHash: abc\r\n\r\n[Metadata]\r\n//keep me\r\nTitle:New\r\nCreator:Someone\r\n\r\n[TimingPoints]\r\n0,500,4,2,0,100,1,0\r\n1000,-50,4,2,0,60,0,1\r\n\r\n[HitObjects]\r\n256,192,0,1,8,0:0:0:0:\r\n"
+
+	if encoded := parsedOsuFile.Encode(); encoded != expected {
+		t.Fatalf("unexpected encoding:\n%s", strings.ReplaceAll(encoded, "\r", ""))
+	}
+
+	reparsedOsuFile, _ := osu_parser.ParseText(parsedOsuFile.Encode())
+
+	if reparsedOsuFile.Metadata.Title != "New" || len(reparsedOsuFile.HitObjects.List) != 1 {
+		t.Fail()
+	}
+}
+
+func TestLosslessAddedSection(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseTextLossless("osu file format v14\n\n[Events]\n0,0,\"bg.jpg\",0,0\n\n[HitObjects]\n256,192,0,1,0,0:0:0:0:")
+
+	parsedOsuFile.Colours.Combos = []osu_parser.Color{{R: 1, G: 2, B: 3}}
+	parsedOsuFile.Events.Events = append(parsedOsuFile.Events.Events, osu_parser.Event{
+		EventType:      osu_parser.EventTypeBreak,
+		BreakTimeBegin: 1000,
+		BreakTimeEnd:   5000,
+	})
+
+	reparsedOsuFile, _ := osu_parser.ParseText(parsedOsuFile.Encode())
+
+	if len(reparsedOsuFile.Colours.Combos) != 1 || len(reparsedOsuFile.Events.Events) != 2 || len(reparsedOsuFile.HitObjects.List) != 1 {
+		t.Fatalf("added values missing:\n%s", parsedOsuFile.Encode())
+	}
+}
+
+func TestLosslessUnmodifiedLF(t *testing.T) {
+	original := "osu file format v14\n\n[General]\nAudioFilename: audio.mp3\n// a comment\n\n[Events]\n//Background and Video events\n0,0,\"bg.jpg\",0,0\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n256,192,0,1,0,0:0:0:0:\n256,192,500,1,2,0:0:0:0:\n"
+
+	parsedOsuFile, err := osu_parser.ParseTextLossless(original)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encoded := parsedOsuFile.Encode(); encoded != original {
+		t.Fatalf("unmodified file didn't encode byte for byte:\n%s", encoded)
+	}
+}
+
+func TestLosslessRemovedInMiddle(t *testing.T) {
+	original := "osu file format v14\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n1000,500,4,2,0,100,1,0\n2000,500,4,2,0,100,1,0\n\n[HitObjects]\n256,192,0,1,0,0:0:0:0\n256,192,500,1,0,0:0:0:0\n256,192,1000,1,0,0:0:0:0\n256,192,1500,1,0,0:0:0:0\n"
+
+	parsedOsuFile, _ := osu_parser.ParseTextLossless(original)
+
+	//Lines which aren't written the way the encoder would write them show if they got rewritten
+	hitObjects := parsedOsuFile.HitObjects.List
+	parsedOsuFile.HitObjects.List = append(append([]osu_parser.HitObject{}, hitObjects[:1]...), hitObjects[2:]...)
+	parsedOsuFile.TimingPoints.TimingPoints = parsedOsuFile.TimingPoints.TimingPoints[1:]
+
+	inserted := hitObjects[0]
+	inserted.Time = 250
+
+	parsedOsuFile.HitObjects.List = append(parsedOsuFile.HitObjects.List[:1], append([]osu_parser.HitObject{inserted}, parsedOsuFile.HitObjects.List[1:]...)...)
+
+	expected := "osu file format v14\n\n[TimingPoints]\n1000,500,4,2,0,100,1,0\n2000,500,4,2,0,100,1,0\n\n[HitObjects]\n256,192,0,1,0,0:0:0:0\n256,192,250,1,0,0:0:0:0:\n256,192,1000,1,0,0:0:0:0\n256,192,1500,1,0,0:0:0:0\n"
+
+	if encoded := parsedOsuFile.Encode(); encoded != expected {
+		t.Fatalf("unexpected encoding:\n%s", encoded)
+	}
+}
+
+func TestLosslessEventsKeepComments(t *testing.T) {
+	original := "osu file format v14\n\n[Events]\n//my background\n0,0,\"bg.jpg\",0,0\n//Break Periods\n2,1000,2000\nSomethingUnknown,1,2\n//Storyboard Layer 0 (Background)\nSprite,Background,Centre,\"a.png\",320,240\n _F,0,0,1000,0,1\n\n[HitObjects]\n256,192,0,1,0,0:0:0:0:\n"
+
+	parsedOsuFile, _ := osu_parser.ParseTextLossless(original)
+
+	parsedOsuFile.Events.Events[1].BreakTimeEnd = 3000
+	parsedOsuFile.Events.Events = append(parsedOsuFile.Events.Events, osu_parser.Event{
+		EventType:      osu_parser.EventTypeBreak,
+		BreakTimeBegin: 5000,
+		BreakTimeEnd:   6000,
+	})
+
+	expected := "osu file format v14\n\n[Events]\n//my background\n0,0,\"bg.jpg\",0,0\n//Break Periods\n2,1000,3000\n2,5000,6000\nSomethingUnknown,1,2\n//Storyboard Layer 0 (Background)\nSprite,Background,Centre,\"a.png\",320,240\n _F,0,0,1000,0,1\n\n[HitObjects]\n256,192,0,1,0,0:0:0:0:\n"
+
+	if encoded := parsedOsuFile.Encode(); encoded != expected {
+		t.Fatalf("unexpected encoding:\n%s", encoded)
+	}
+
+	parsedOsuFile.Events.Storyboard.Objects = nil
+
+	reparsedOsuFile, _ := osu_parser.ParseText(parsedOsuFile.Encode())
+
+	if len(reparsedOsuFile.Events.Storyboard.Objects) != 0 || len(reparsedOsuFile.Events.Events) != 3 {
+		t.Fatalf("storyboard not rewritten:\n%s", parsedOsuFile.Encode())
+	}
+
+	if !strings.Contains(parsedOsuFile.Encode(), "//my background\n") || !strings.Contains(parsedOsuFile.Encode(), "SomethingUnknown,1,2\n") {
+		t.Fatalf("comments or unknown lines dropped:\n%s", parsedOsuFile.Encode())
+	}
+}
+
+// Random edits always encode to exactly the edited hit objects
+func TestLosslessRandomEdits(t *testing.T) {
+	original := "osu file format v14\n\n[HitObjects]\n"
+
+	for i := 0; i < 200; i++ {
+		original += fmt.Sprintf("%d,192,%d,1,0,0:0:0:0:\n", i%512, i*100)
+	}
+
+	random := rand.New(rand.NewSource(1))
+
+	for round := 0; round < 50; round++ {
+		parsedOsuFile, _ := osu_parser.ParseTextLossless(original)
+		hitObjects := parsedOsuFile.HitObjects.List
+
+		for edit := 0; edit < random.Intn(20); edit++ {
+			index := random.Intn(len(hitObjects))
+
+			switch random.Intn(3) {
+			case 0:
+				hitObjects = append(hitObjects[:index:index], hitObjects[index+1:]...)
+			case 1:
+				inserted := hitObjects[index]
+				inserted.Position.Y = float64(random.Intn(384))
+
+				hitObjects = append(hitObjects[:index:index], append([]osu_parser.HitObject{inserted}, hitObjects[index:]...)...)
+			default:
+				hitObjects[index].HitSound = osu_parser.HitSoundTypeWhistle
+			}
+		}
+
+		parsedOsuFile.HitObjects.List = hitObjects
+
+		reparsedOsuFile, _ := osu_parser.ParseText(parsedOsuFile.Encode())
+
+		if len(reparsedOsuFile.HitObjects.List) != len(hitObjects) {
+			t.Fatalf("expected %d hit objects, got %d", len(hitObjects), len(reparsedOsuFile.HitObjects.List))
+		}
+
+		for i := range hitObjects {
+			got := reparsedOsuFile.HitObjects.List[i]
+
+			if got.Position != hitObjects[i].Position || got.Time != hitObjects[i].Time || got.HitSound != hitObjects[i].HitSound {
+				t.Fatalf("round %d: hit object %d differs", round, i)
+			}
+		}
+	}
+}
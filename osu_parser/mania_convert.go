@@ -0,0 +1,1144 @@
+package osu_parser
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	//How many of the last notes the density of the map is worked out from
+	maniaDensityNotes = 7
+	maniaMaxKeyCount  = 10
+)
+
+// How the notes of one object get placed, combined as flags
+type maniaPatternType int
+
+const (
+	maniaPatternForceStack maniaPatternType = 1 << iota
+	maniaPatternForceNotStack
+	maniaPatternKeepSingle
+	maniaPatternLowProbability
+	maniaPatternStair
+	maniaPatternReverse
+	maniaPatternCycle
+	maniaPatternMirror
+	maniaPatternGathered
+)
+
+// osu!stable's xorshift random number generator. Converts depend on getting the same numbers as the game
+type legacyRandom struct {
+	x, y, z, w uint32
+}
+
+func newLegacyRandom(seed int32) *legacyRandom {
+	return &legacyRandom{
+		x: uint32(seed),
+		y: 842502087,
+		z: 3579807591,
+		w: 273326509,
+	}
+}
+
+func (random *legacyRandom) nextUint() uint32 {
+	t := random.x ^ (random.x << 11)
+
+	random.x, random.y, random.z = random.y, random.z, random.w
+	random.w = random.w ^ (random.w >> 19) ^ t ^ (t >> 8)
+
+	return random.w
+}
+
+// Between 0 and 1, never 1 itself
+func (random *legacyRandom) nextDouble() float64 {
+	return float64(random.nextUint()&math.MaxInt32) / (math.MaxInt32 + 1.0)
+}
+
+// From lower up to, but not including, upper
+func (random *legacyRandom) next(lower int, upper int) int {
+	return int(float64(lower) + random.nextDouble()*float64(upper-lower))
+}
+
+// Notes made for one object, or one part of it
+type maniaPattern struct {
+	notes []maniaNote
+	//Columns with a note in them, in the order they got filled
+	columns []int
+}
+
+func (pattern *maniaPattern) add(note maniaNote) {
+	pattern.notes = append(pattern.notes, note)
+
+	if !pattern.hasColumn(note.column) {
+		pattern.columns = append(pattern.columns, note.column)
+	}
+}
+
+func (pattern *maniaPattern) addPattern(other *maniaPattern) {
+	for _, note := range other.notes {
+		pattern.add(note)
+	}
+}
+
+func (pattern *maniaPattern) hasColumn(column int) bool {
+	for _, filled := range pattern.columns {
+		if filled == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// State carried from one object to the next while converting
+type maniaConverter struct {
+	osuFile  *OsuFile
+	random   *legacyRandom
+	keyCount int
+	//With 8 keys the first column is a special one, random notes don't go there
+	randomStart int
+	//Rough difficulty of the original map, harder maps get more chords
+	conversionDifficulty float64
+
+	lastPattern  *maniaPattern
+	lastTime     float64
+	lastPosition Vec2
+	noteTimes    []float64
+	density      float64
+
+	//Set when a pattern had nowhere left to go, the game gives up on the conversion then
+	err error
+}
+
+// One object getting converted
+type maniaObjectGenerator struct {
+	*maniaConverter
+
+	hitObject      *HitObject
+	hitObjectIndex int
+	previous       *maniaPattern
+	convertType    maniaPatternType
+
+	//Slider specific, in whole milliseconds like the game
+	startTime       int
+	endTime         int
+	segmentDuration int
+	spanCount       int
+}
+
+// Key count the game picks for a converted map without a key mod, from how many sliders and spinners it has
+func (osuFile *OsuFile) maniaConvertKeyCount() int {
+	durationObjects := 0
+
+	for i := range osuFile.HitObjects.List {
+		if osuFile.HitObjects.List[i].Type != HitObjectTypeCircle {
+			durationObjects++
+		}
+	}
+
+	percentSliderOrSpinner := float64(float32(durationObjects) / float32(len(osuFile.HitObjects.List)))
+	circleSize := math.RoundToEven(float64(float32(osuFile.Difficulty.CircleSize)))
+	overallDifficulty := math.RoundToEven(float64(float32(osuFile.Difficulty.OverallDifficulty)))
+
+	switch {
+	case percentSliderOrSpinner < 0.2:
+		return 7
+	case percentSliderOrSpinner < 0.3 || circleSize >= 5:
+		if overallDifficulty > 5 {
+			return 7
+		}
+
+		return 6
+	case percentSliderOrSpinner > 0.6:
+		if overallDifficulty > 4 {
+			return 5
+		}
+
+		return 4
+	}
+
+	return max(4, min(int(overallDifficulty)+1, 7))
+}
+
+// Between 0 and 12, from drain rate, approach rate and how many objects there are per second of drain time
+func (osuFile *OsuFile) maniaConversionDifficulty(order []int) float64 {
+	hitObjects := osuFile.HitObjects.List
+	firstTime, lastTime := 0.0, 0.0
+
+	if len(order) != 0 {
+		firstTime = hitObjects[order[0]].Time
+		lastTime = hitObjects[order[len(order)-1]].Time
+	}
+
+	breakTime := 0.0
+
+	for _, event := range osuFile.Events.Events {
+		if event.EventType == EventTypeBreak {
+			breakTime += float64(event.BreakTimeEnd - event.BreakTimeBegin)
+		}
+	}
+
+	drainTime := int((lastTime - firstTime - breakTime) / 1000)
+
+	if drainTime == 0 {
+		drainTime = 10000
+	}
+
+	difficulty := osuFile.Difficulty
+	approachRate := float32(math.Max(4, math.Min(7, difficulty.ApproachRate)))
+	conversionDifficulty := float64(float32(difficulty.HPDrainRate)+approachRate)/1.5 + float64(len(hitObjects))/float64(drainTime)*9
+
+	return math.Min(conversionDifficulty/38*5/1.15, 12)
+}
+
+// The osu!standard map as osu!mania plays it with keyCount columns, 0 picking the key count the game would.
+// Notes come out in order of time, along with the key count
+func (osuFile *OsuFile) maniaObjects(keyCount int) ([]maniaNote, int, error) {
+	if keyCount == 0 {
+		keyCount = osuFile.maniaConvertKeyCount()
+	}
+
+	if keyCount < 1 || keyCount > maniaMaxKeyCount {
+		return nil, keyCount, fmt.Errorf("%w: %d keys", ErrUnsupportedConversion, keyCount)
+	}
+
+	//Objects written out of order get converted in order of time
+	order := make([]int, len(osuFile.HitObjects.List))
+
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return osuFile.HitObjects.List[order[a]].Time < osuFile.HitObjects.List[order[b]].Time
+	})
+
+	difficulty := osuFile.Difficulty
+	seed := int32(math.RoundToEven(float64(float32(difficulty.HPDrainRate)+float32(difficulty.CircleSize))))*20 +
+		int32(float64(float32(difficulty.OverallDifficulty))*41.2) +
+		int32(math.RoundToEven(float64(float32(difficulty.ApproachRate))))
+
+	converter := &maniaConverter{
+		osuFile:              osuFile,
+		random:               newLegacyRandom(seed),
+		keyCount:             keyCount,
+		conversionDifficulty: osuFile.maniaConversionDifficulty(order),
+		lastPattern:          &maniaPattern{},
+		density:              math.MaxInt32,
+	}
+
+	if keyCount == 8 {
+		converter.randomStart = 1
+	}
+
+	notes := []maniaNote{}
+
+	for _, i := range order {
+		hitObject := &osuFile.HitObjects.List[i]
+
+		generator := &maniaObjectGenerator{
+			maniaConverter: converter,
+			hitObject:      hitObject,
+			hitObjectIndex: i,
+			previous:       converter.lastPattern,
+		}
+
+		switch hitObject.Type {
+		case HitObjectTypeSlider:
+			generator.setSliderTiming()
+
+			for span := 0; span <= generator.spanCount; span++ {
+				time := hitObject.Time + float64(generator.segmentDuration*span)
+
+				converter.recordNote(time, hitObject.Position)
+				converter.computeDensity(time)
+			}
+
+			for _, pattern := range generator.pathPatterns() {
+				notes = append(notes, pattern.notes...)
+				converter.lastPattern = pattern
+			}
+		case HitObjectTypeSpinner, HitObjectTypeHold:
+			converter.recordNote(float64(hitObject.EndTime), Vec2{X: 256, Y: 192})
+			converter.computeDensity(float64(hitObject.EndTime))
+
+			//Spinners don't become the pattern the next object works from
+			notes = append(notes, generator.endTimePattern().notes...)
+		default:
+			converter.computeDensity(hitObject.Time)
+
+			pattern := generator.hitObjectPattern()
+
+			converter.recordNote(hitObject.Time, hitObject.Position)
+
+			notes = append(notes, pattern.notes...)
+			converter.lastPattern = pattern
+		}
+	}
+
+	if converter.err != nil {
+		return nil, keyCount, converter.err
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].startTime < notes[j].startTime
+	})
+
+	return notes, keyCount, nil
+}
+
+func (converter *maniaConverter) recordNote(time float64, position Vec2) {
+	converter.lastTime = time
+	converter.lastPosition = position
+}
+
+func (converter *maniaConverter) computeDensity(time float64) {
+	converter.noteTimes = append(converter.noteTimes, time)
+
+	if len(converter.noteTimes) > maniaDensityNotes {
+		converter.noteTimes = converter.noteTimes[1:]
+	}
+
+	if count := len(converter.noteTimes); count >= 2 {
+		converter.density = (converter.noteTimes[count-1] - converter.noteTimes[0]) / float64(count)
+	}
+}
+
+// Column under an x position, the special column of 8 keys only gets used with allowSpecial
+func (converter *maniaConverter) column(x float64, allowSpecial bool) int {
+	if allowSpecial && converter.keyCount == 8 {
+		column := int(math.Floor(float64(float32(x) / (float32(512) / 7))))
+
+		return max(0, min(6, column)) + 1
+	}
+
+	column := int(math.Floor(float64(float32(x) / (float32(512) / float32(converter.keyCount)))))
+
+	return max(0, min(converter.keyCount-1, column))
+}
+
+func (converter *maniaConverter) randomColumn() int {
+	return converter.random.next(converter.randomStart, converter.keyCount)
+}
+
+// 1 to 6 notes, p2 being the chance of at least 2 notes, p3 of at least 3 and so on
+func (converter *maniaConverter) randomNoteCount(p2 float64, p3 float64, p4 float64, p5 float64, p6 float64) int {
+	value := converter.random.nextDouble()
+
+	switch {
+	case value >= 1-p6:
+		return 6
+	case value >= 1-p5:
+		return 5
+	case value >= 1-p4:
+		return 4
+	case value >= 1-p3:
+		return 3
+	case value >= 1-p2:
+		return 2
+	}
+
+	return 1
+}
+
+// The first column from initial on which is valid and empty in all patterns.
+// Columns after the initial one come from next, random columns between lower and upper if it's nil
+func (converter *maniaConverter) findAvailableColumn(initial int, lower int, upper int, next func(int) int, valid func(int) bool, patterns ...*maniaPattern) int {
+	isValid := func(column int) bool {
+		if valid != nil && !valid(column) {
+			return false
+		}
+
+		for _, pattern := range patterns {
+			if pattern.hasColumn(column) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if next == nil {
+		next = func(int) int {
+			return converter.random.next(lower, upper)
+		}
+	}
+
+	if isValid(initial) {
+		return initial
+	}
+
+	hasValidColumns := false
+
+	for column := lower; column < upper && !hasValidColumns; column++ {
+		hasValidColumns = isValid(column)
+	}
+
+	if !hasValidColumns {
+		converter.err = fmt.Errorf("%w: not enough columns for a pattern", ErrUnsupportedConversion)
+
+		return initial
+	}
+
+	for {
+		initial = next(initial)
+
+		if isValid(initial) {
+			return initial
+		}
+	}
+}
+
+func (generator *maniaObjectGenerator) hasSound(sound HitSoundType) bool {
+	return generator.hitObject.HitSound&sound != 0
+}
+
+// Circles turn into one or more notes at the same time, depending on how close they are to the object before
+func (generator *maniaObjectGenerator) hitObjectPattern() *maniaPattern {
+	osuFile := generator.osuFile
+	hitObject := generator.hitObject
+	keyCount := generator.keyCount
+
+	beatLength := osuFile.TimingPoints.BeatLengthAt(hitObject.Time)
+	positionSeparation := hitObject.Position.sub(generator.lastPosition).length()
+	timeSeparation := hitObject.Time - generator.lastTime
+
+	switch {
+	case timeSeparation <= 80:
+		//More than 187 bpm
+		generator.convertType |= maniaPatternForceNotStack | maniaPatternKeepSingle
+	case timeSeparation <= 95:
+		//More than 157 bpm
+		generator.convertType |= maniaPatternForceNotStack | maniaPatternKeepSingle | maniaPatternStair
+	case timeSeparation <= 105:
+		//More than 140 bpm
+		generator.convertType |= maniaPatternForceNotStack | maniaPatternLowProbability
+	case timeSeparation <= 125:
+		//More than 120 bpm
+		generator.convertType |= maniaPatternForceNotStack
+	case timeSeparation <= 135 && positionSeparation < 20:
+		//More than 111 bpm stream
+		generator.convertType |= maniaPatternCycle | maniaPatternKeepSingle
+	case timeSeparation <= 150 && positionSeparation < 20:
+		//More than 100 bpm stream
+		generator.convertType |= maniaPatternForceStack | maniaPatternLowProbability
+	case positionSeparation < 20 && generator.density >= beatLength/2.5:
+		//Low density stream
+		generator.convertType |= maniaPatternReverse | maniaPatternLowProbability
+	case generator.density < beatLength/2.5 || osuFile.TimingPoints.IsKiaiAt(hitObject.Time):
+		//High density
+	default:
+		generator.convertType |= maniaPatternLowProbability
+	}
+
+	if generator.convertType&maniaPatternKeepSingle == 0 {
+		if generator.hasSound(HitSoundTypeFinish) && keyCount != 8 {
+			generator.convertType |= maniaPatternMirror
+		} else if generator.hasSound(HitSoundTypeClap) {
+			generator.convertType |= maniaPatternGathered
+		}
+	}
+
+	pattern := &maniaPattern{}
+
+	if keyCount == 1 {
+		generator.addNote(pattern, 0)
+
+		return pattern
+	}
+
+	previous := generator.previous
+	lastColumn := 0
+
+	if len(previous.notes) != 0 {
+		lastColumn = previous.notes[0].column
+	}
+
+	switch {
+	case generator.convertType&maniaPatternReverse != 0 && len(previous.notes) != 0:
+		//The last pattern mirrored
+		for column := generator.randomStart; column < keyCount; column++ {
+			if previous.hasColumn(column) {
+				generator.addNote(pattern, generator.randomStart+keyCount-column-1)
+			}
+		}
+
+		return pattern
+	case generator.convertType&maniaPatternCycle != 0 && len(previous.notes) == 1 &&
+		//Leaving out the special column of 8 keys and the middle column of odd key counts
+		(keyCount != 8 || lastColumn != 0) && (keyCount%2 == 0 || lastColumn != keyCount/2):
+		generator.addNote(pattern, generator.randomStart+keyCount-lastColumn-1)
+
+		return pattern
+	case generator.convertType&maniaPatternForceStack != 0 && len(previous.notes) != 0:
+		//On the same columns as the last pattern
+		for column := generator.randomStart; column < keyCount; column++ {
+			if previous.hasColumn(column) {
+				generator.addNote(pattern, column)
+			}
+		}
+
+		return pattern
+	case generator.convertType&maniaPatternStair != 0 && len(previous.notes) == 1:
+		//One column further, going back to the start after the last one
+		column := lastColumn + 1
+
+		if column == keyCount {
+			column = generator.randomStart
+		}
+
+		generator.addNote(pattern, column)
+
+		return pattern
+	case generator.convertType&maniaPatternKeepSingle != 0:
+		return generator.randomNotes(1)
+	case generator.convertType&maniaPatternMirror != 0:
+		switch {
+		case generator.conversionDifficulty > 6.5:
+			return generator.randomPatternWithMirrored(0.12, 0.38, 0.12)
+		case generator.conversionDifficulty > 4:
+			return generator.randomPatternWithMirrored(0.12, 0.17, 0)
+		}
+
+		return generator.randomPatternWithMirrored(0.12, 0, 0)
+	}
+
+	lowProbability := generator.convertType&maniaPatternLowProbability != 0
+
+	switch {
+	case generator.conversionDifficulty > 6.5:
+		if lowProbability {
+			return generator.randomPattern(0.78, 0.42, 0, 0)
+		}
+
+		return generator.randomPattern(1, 0.62, 0, 0)
+	case generator.conversionDifficulty > 4:
+		if lowProbability {
+			return generator.randomPattern(0.35, 0.08, 0, 0)
+		}
+
+		return generator.randomPattern(0.52, 0.15, 0, 0)
+	case generator.conversionDifficulty > 2:
+		if lowProbability {
+			return generator.randomPattern(0.18, 0, 0, 0)
+		}
+
+		return generator.randomPattern(0.45, 0, 0, 0)
+	}
+
+	return generator.randomPattern(0, 0, 0, 0)
+}
+
+// Whistle and clap together put a note on the special column of 8 keys
+func (generator *maniaObjectGenerator) hasSpecialColumn() bool {
+	return generator.hasSound(HitSoundTypeClap) && generator.hasSound(HitSoundTypeFinish)
+}
+
+func (generator *maniaObjectGenerator) randomPattern(p2 float64, p3 float64, p4 float64, p5 float64) *maniaPattern {
+	pattern := generator.randomNotes(generator.hitObjectNoteCount(p2, p3, p4, p5))
+
+	if generator.randomStart > 0 && generator.hasSpecialColumn() {
+		generator.addNote(pattern, 0)
+	}
+
+	return pattern
+}
+
+func (generator *maniaObjectGenerator) randomNotes(noteCount int) *maniaPattern {
+	pattern := &maniaPattern{}
+	allowStacking := generator.convertType&maniaPatternForceNotStack == 0
+
+	if !allowStacking {
+		noteCount = min(noteCount, generator.keyCount-generator.randomStart-len(generator.previous.columns))
+	}
+
+	nextColumn := func(last int) int {
+		if generator.convertType&maniaPatternGathered == 0 {
+			return generator.randomColumn()
+		}
+
+		last++
+
+		if last == generator.keyCount {
+			last = generator.randomStart
+		}
+
+		return last
+	}
+
+	column := generator.column(generator.hitObject.Position.X, true)
+
+	for i := 0; i < noteCount; i++ {
+		if allowStacking {
+			column = generator.findAvailableColumn(column, generator.randomStart, generator.keyCount, nextColumn, nil, pattern)
+		} else {
+			column = generator.findAvailableColumn(column, generator.randomStart, generator.keyCount, nextColumn, nil, pattern, generator.previous)
+		}
+
+		generator.addNote(pattern, column)
+	}
+
+	return pattern
+}
+
+// Notes on one half, mirrored onto the other half, sometimes with one in the middle
+func (generator *maniaObjectGenerator) randomPatternWithMirrored(centreProbability float64, p2 float64, p3 float64) *maniaPattern {
+	if generator.convertType&maniaPatternForceNotStack != 0 {
+		return generator.randomPattern(0.5+p2/2, p2, (p2+p3)/2, p3)
+	}
+
+	keyCount := generator.keyCount
+
+	switch keyCount {
+	case 2:
+		centreProbability, p2, p3 = 0, 0, 0
+	case 3:
+		centreProbability, p2, p3 = math.Min(centreProbability, 0.03), 0, 0
+	case 4:
+		//The game takes these as the chance of not getting the notes, so they get turned around for doubling
+		centreProbability, p2, p3 = 0, 1-math.Max((1-p2)*2, 0.8), 0
+	case 5:
+		centreProbability, p3 = math.Min(centreProbability, 0.03), 0
+	case 6:
+		centreProbability, p2, p3 = 0, 1-math.Max((1-p2)*2, 0.5), 1-math.Max((1-p3)*2, 0.85)
+	}
+
+	p2 = math.Max(0, math.Min(1, p2))
+	p3 = math.Max(0, math.Min(1, p3))
+
+	centreValue := generator.random.nextDouble()
+	noteCount := generator.randomNoteCount(p2, p3, 0, 0, 0)
+	addToCentre := keyCount%2 != 0 && noteCount != 3 && centreValue > 1-centreProbability
+
+	pattern := &maniaPattern{}
+	columnLimit := keyCount / 2
+
+	if keyCount%2 != 0 {
+		columnLimit = (keyCount - 1) / 2
+	}
+
+	column := generator.random.next(generator.randomStart, columnLimit)
+
+	for i := 0; i < noteCount; i++ {
+		column = generator.findAvailableColumn(column, generator.randomStart, columnLimit, nil, nil, pattern)
+
+		generator.addNote(pattern, column)
+		generator.addNote(pattern, generator.randomStart+keyCount-column-1)
+	}
+
+	if addToCentre {
+		generator.addNote(pattern, keyCount/2)
+	}
+
+	if generator.randomStart > 0 && generator.hasSpecialColumn() {
+		generator.addNote(pattern, 0)
+	}
+
+	return pattern
+}
+
+// Note count of a circle, lower key counts allow less notes at once and claps always give at least 2
+func (generator *maniaObjectGenerator) hitObjectNoteCount(p2 float64, p3 float64, p4 float64, p5 float64) int {
+	switch generator.keyCount {
+	case 2:
+		p2, p3, p4, p5 = 0, 0, 0, 0
+	case 3:
+		p2, p3, p4, p5 = math.Min(p2, 0.1), 0, 0, 0
+	case 4:
+		p2, p3, p4, p5 = math.Min(p2, 0.23), math.Min(p3, 0.04), 0, 0
+	case 5:
+		p3, p4, p5 = math.Min(p3, 0.15), math.Min(p4, 0.03), 0
+	}
+
+	if generator.hasSound(HitSoundTypeClap) {
+		p2 = 1
+	}
+
+	return generator.randomNoteCount(p2, p3, p4, p5, 0)
+}
+
+func (generator *maniaObjectGenerator) addNote(pattern *maniaPattern, column int) {
+	pattern.add(maniaNote{
+		column:         column,
+		startTime:      generator.hitObject.Time,
+		endTime:        generator.hitObject.Time,
+		hitObjectIndex: generator.hitObjectIndex,
+		node:           -1,
+	})
+}
+
+// Spinners turn into a single hold, or a note if they're too short
+func (generator *maniaObjectGenerator) endTimePattern() *maniaPattern {
+	hitObject := generator.hitObject
+	pattern := &maniaPattern{}
+
+	column := 0
+
+	switch {
+	case generator.keyCount == 8 && generator.hasSound(HitSoundTypeFinish) && float64(hitObject.EndTime)-hitObject.Time < 1000:
+		column = 0
+	case generator.keyCount == 8:
+		column = generator.findAvailableColumn(generator.randomColumn(), generator.randomStart, generator.keyCount, nil, nil, generator.previous)
+	default:
+		column = generator.random.next(0, generator.keyCount)
+	}
+
+	note := maniaNote{
+		column:         column,
+		startTime:      hitObject.Time,
+		endTime:        hitObject.Time,
+		hitObjectIndex: generator.hitObjectIndex,
+		node:           -1,
+	}
+
+	if float64(hitObject.EndTime)-hitObject.Time >= 100 {
+		note.endTime = float64(hitObject.EndTime)
+	}
+
+	pattern.add(note)
+
+	return pattern
+}
+
+// Slider timing the way the converter sees it, rounded to whole milliseconds
+func (generator *maniaObjectGenerator) setSliderTiming() {
+	osuFile := generator.osuFile
+	hitObject := generator.hitObject
+
+	beatLength := osuFile.TimingPoints.BeatLengthAt(hitObject.Time)
+
+	//Green lines slow the slider down through the beat length, with a limit below slider velocities of 0.01
+	if controlPoint := osuFile.TimingPoints.ControlPointAt(hitObject.Time); controlPoint.BeatLength < 0 {
+		beatLength *= float64(float32(math.Max(10, math.Min(10000, -controlPoint.BeatLength)))) / 100
+	}
+
+	sliderMultiplier := osuFile.Difficulty.SliderMultiplier
+
+	if !(sliderMultiplier > 0) {
+		sliderMultiplier = defaultSliderMultiplier
+	}
+
+	generator.spanCount = max(1, int(hitObject.RepeatCount))
+	generator.startTime = int(math.RoundToEven(hitObject.Time))
+	generator.endTime = int(math.Floor(float64(generator.startTime) + hitObject.declaredLength()*beatLength*float64(generator.spanCount)*0.01/sliderMultiplier))
+	generator.segmentDuration = (generator.endTime - generator.startTime) / generator.spanCount
+
+	if !osuFile.TimingPoints.IsKiaiAt(hitObject.Time) {
+		generator.convertType = maniaPatternLowProbability
+	}
+}
+
+// Sounds of the slider node at time
+func (generator *maniaObjectGenerator) soundAt(time int) HitSoundType {
+	node := generator.nodeAt(time)
+
+	if node < len(generator.hitObject.SoundTypes) {
+		return generator.hitObject.SoundTypes[node]
+	}
+
+	return generator.hitObject.HitSound
+}
+
+func (generator *maniaObjectGenerator) nodeAt(time int) int {
+	if generator.segmentDuration == 0 {
+		return 0
+	}
+
+	return max(0, (time-generator.startTime)/generator.segmentDuration)
+}
+
+// Sliders turn into notes on every node, stairs, chords or holds. Holds ending with the slider
+// come out as a separate pattern, which is the one the next object works from
+func (generator *maniaObjectGenerator) pathPatterns() []*maniaPattern {
+	original := generator.pathPattern()
+
+	if len(original.notes) == 1 {
+		return []*maniaPattern{original}
+	}
+
+	intermediate := &maniaPattern{}
+	endTimePattern := &maniaPattern{}
+
+	for _, note := range original.notes {
+		if generator.endTime != int(math.RoundToEven(note.endTime)) {
+			intermediate.add(note)
+		} else {
+			endTimePattern.add(note)
+		}
+	}
+
+	return []*maniaPattern{intermediate, endTimePattern}
+}
+
+func (generator *maniaObjectGenerator) pathPattern() *maniaPattern {
+	startTime := generator.startTime
+	segmentDuration := generator.segmentDuration
+
+	if generator.keyCount == 1 {
+		pattern := &maniaPattern{}
+		generator.addPathNote(pattern, 0, startTime, generator.endTime)
+
+		return pattern
+	}
+
+	if generator.spanCount > 1 {
+		switch {
+		case segmentDuration <= 90:
+			return generator.randomHoldNotes(startTime, 1)
+		case segmentDuration <= 120:
+			generator.convertType |= maniaPatternForceNotStack
+
+			return generator.randomPathNotes(startTime, generator.spanCount+1)
+		case segmentDuration <= 160:
+			return generator.stair(startTime)
+		case segmentDuration <= 200 && generator.conversionDifficulty > 3:
+			return generator.randomMultipleNotes(startTime)
+		case generator.endTime-startTime >= 4000:
+			return generator.nRandomNotes(startTime, 0.23, 0, 0)
+		case segmentDuration > 400 && generator.spanCount < generator.keyCount-1-generator.randomStart:
+			return generator.tiledHoldNotes(startTime)
+		}
+
+		return generator.holdAndNormalNotes(startTime)
+	}
+
+	if segmentDuration <= 110 {
+		if len(generator.previous.columns) < generator.keyCount {
+			generator.convertType |= maniaPatternForceNotStack
+		} else {
+			generator.convertType &^= maniaPatternForceNotStack
+		}
+
+		if segmentDuration < 80 {
+			return generator.randomPathNotes(startTime, 1)
+		}
+
+		return generator.randomPathNotes(startTime, 2)
+	}
+
+	lowProbability := generator.convertType&maniaPatternLowProbability != 0
+
+	switch {
+	case generator.conversionDifficulty > 6.5:
+		if lowProbability {
+			return generator.nRandomNotes(startTime, 0.78, 0.3, 0)
+		}
+
+		return generator.nRandomNotes(startTime, 0.85, 0.36, 0.03)
+	case generator.conversionDifficulty > 4:
+		if lowProbability {
+			return generator.nRandomNotes(startTime, 0.43, 0.08, 0)
+		}
+
+		return generator.nRandomNotes(startTime, 0.56, 0.18, 0)
+	case generator.conversionDifficulty > 2.5:
+		if lowProbability {
+			return generator.nRandomNotes(startTime, 0.3, 0, 0)
+		}
+
+		return generator.nRandomNotes(startTime, 0.37, 0.08, 0)
+	}
+
+	if lowProbability {
+		return generator.nRandomNotes(startTime, 0.17, 0, 0)
+	}
+
+	return generator.nRandomNotes(startTime, 0.27, 0, 0)
+}
+
+// Holds over the whole slider, on columns the last pattern didn't use as long as there are any
+func (generator *maniaObjectGenerator) randomHoldNotes(startTime int, noteCount int) *maniaPattern {
+	pattern := &maniaPattern{}
+	usableColumns := generator.keyCount - generator.randomStart - len(generator.previous.columns)
+	column := generator.randomColumn()
+
+	for i := 0; i < min(usableColumns, noteCount); i++ {
+		column = generator.findAvailableColumn(column, generator.randomStart, generator.keyCount, nil, nil, pattern, generator.previous)
+		generator.addPathNote(pattern, column, startTime, generator.endTime)
+	}
+
+	for i := 0; i < noteCount-usableColumns; i++ {
+		column = generator.findAvailableColumn(column, generator.randomStart, generator.keyCount, nil, nil, pattern)
+		generator.addPathNote(pattern, column, startTime, generator.endTime)
+	}
+
+	return pattern
+}
+
+// One note on every node, never on the same column twice in a row
+func (generator *maniaObjectGenerator) randomPathNotes(startTime int, noteCount int) *maniaPattern {
+	pattern := &maniaPattern{}
+	column := generator.column(generator.hitObject.Position.X, true)
+
+	if generator.convertType&maniaPatternForceNotStack != 0 && len(generator.previous.columns) < generator.keyCount {
+		column = generator.findAvailableColumn(column, generator.randomStart, generator.keyCount, nil, nil, generator.previous)
+	}
+
+	lastColumn := column
+
+	for i := 0; i < noteCount; i++ {
+		generator.addPathNote(pattern, column, startTime, startTime)
+
+		column = generator.findAvailableColumn(column, generator.randomStart, generator.keyCount, nil, func(column int) bool {
+			return column != lastColumn
+		})
+		lastColumn = column
+		startTime += generator.segmentDuration
+	}
+
+	return pattern
+}
+
+// One note on every node, going across the columns and turning around at the edges
+func (generator *maniaObjectGenerator) stair(startTime int) *maniaPattern {
+	pattern := &maniaPattern{}
+	column := generator.column(generator.hitObject.Position.X, true)
+	increasing := generator.random.nextDouble() > 0.5
+
+	for i := 0; i <= generator.spanCount; i++ {
+		generator.addPathNote(pattern, column, startTime, startTime)
+		startTime += generator.segmentDuration
+
+		switch {
+		case increasing && column >= generator.keyCount-1:
+			increasing = false
+			column--
+		case increasing:
+			column++
+		case column <= generator.randomStart:
+			increasing = true
+			column++
+		default:
+			column--
+		}
+	}
+
+	return pattern
+}
+
+// One or two notes on every node
+func (generator *maniaObjectGenerator) randomMultipleNotes(startTime int) *maniaPattern {
+	pattern := &maniaPattern{}
+	keyCount := generator.keyCount
+
+	legacy := 0
+
+	if keyCount >= 4 && keyCount <= 8 {
+		legacy = 1
+	}
+
+	interval := generator.random.next(1, keyCount-legacy)
+	column := generator.column(generator.hitObject.Position.X, true)
+
+	for i := 0; i <= generator.spanCount; i++ {
+		generator.addPathNote(pattern, column, startTime, startTime)
+
+		column += interval
+
+		if column >= keyCount-generator.randomStart {
+			column = column - keyCount - generator.randomStart + legacy
+		}
+
+		column += generator.randomStart
+
+		//Not too many doubles in a row on 2 keys
+		if keyCount > 2 {
+			generator.addPathNote(pattern, column, startTime, startTime)
+		}
+
+		column = generator.randomColumn()
+		startTime += generator.segmentDuration
+	}
+
+	return pattern
+}
+
+// Holds over the whole slider, how many depends on the probabilities and the slider's sounds
+func (generator *maniaObjectGenerator) nRandomNotes(startTime int, p2 float64, p3 float64, p4 float64) *maniaPattern {
+	switch generator.keyCount {
+	case 2:
+		p2, p3, p4 = 0, 0, 0
+	case 3:
+		p2, p3, p4 = math.Min(p2, 0.1), 0, 0
+	case 4:
+		p2, p3, p4 = math.Min(p2, 0.3), math.Min(p3, 0.04), 0
+	case 5:
+		p2, p3, p4 = math.Min(p2, 0.34), math.Min(p3, 0.1), math.Min(p4, 0.03)
+	}
+
+	doubleSounds := HitSoundTypeClap | HitSoundTypeFinish
+
+	if generator.convertType&maniaPatternLowProbability == 0 && (generator.hasSound(doubleSounds) || generator.soundAt(generator.startTime)&doubleSounds != 0) {
+		p2 = 1
+	}
+
+	return generator.randomHoldNotes(startTime, generator.randomNoteCount(p2, p3, p4, 0, 0))
+}
+
+// A stair of holds, each starting one node later and all of them ending together
+func (generator *maniaObjectGenerator) tiledHoldNotes(startTime int) *maniaPattern {
+	pattern := &maniaPattern{}
+	columnRepeat := min(generator.spanCount, generator.keyCount)
+
+	//Not always the same as the slider's end time, because of the rounding of the segments
+	endTime := startTime + generator.segmentDuration*generator.spanCount
+
+	column := generator.column(generator.hitObject.Position.X, true)
+
+	if generator.convertType&maniaPatternForceNotStack != 0 && len(generator.previous.columns) < generator.keyCount {
+		column = generator.findAvailableColumn(column, generator.randomStart, generator.keyCount, nil, nil, generator.previous)
+	}
+
+	for i := 0; i < columnRepeat; i++ {
+		column = generator.findAvailableColumn(column, generator.randomStart, generator.keyCount, nil, nil, pattern)
+		generator.addPathNote(pattern, column, startTime, endTime)
+		startTime += generator.segmentDuration
+	}
+
+	return pattern
+}
+
+// A hold over the whole slider, with notes on the other columns on every node
+func (generator *maniaObjectGenerator) holdAndNormalNotes(startTime int) *maniaPattern {
+	pattern := &maniaPattern{}
+	keyCount := generator.keyCount
+
+	holdColumn := generator.column(generator.hitObject.Position.X, true)
+
+	if generator.convertType&maniaPatternForceNotStack != 0 && len(generator.previous.columns) < keyCount {
+		holdColumn = generator.findAvailableColumn(holdColumn, generator.randomStart, keyCount, nil, nil, generator.previous)
+	}
+
+	generator.addPathNote(pattern, holdColumn, startTime, generator.endTime)
+
+	column := generator.randomColumn()
+	noteCount := 0
+
+	switch {
+	case generator.conversionDifficulty > 6.5:
+		noteCount = generator.randomNoteCount(0.63, 0, 0, 0, 0)
+	case generator.conversionDifficulty > 4 && keyCount < 6:
+		noteCount = generator.randomNoteCount(0.12, 0, 0, 0, 0)
+	case generator.conversionDifficulty > 4:
+		noteCount = generator.randomNoteCount(0.45, 0, 0, 0, 0)
+	case generator.conversionDifficulty > 2.5 && keyCount < 6:
+		noteCount = generator.randomNoteCount(0, 0, 0, 0, 0)
+	case generator.conversionDifficulty > 2.5:
+		noteCount = generator.randomNoteCount(0.24, 0, 0, 0, 0)
+	}
+
+	noteCount = min(keyCount-1, noteCount)
+
+	//Without any additions on the head there's only the hold there
+	ignoreHead := generator.soundAt(startTime)&(HitSoundTypeWhistle|HitSoundTypeFinish|HitSoundTypeClap) == 0
+
+	for i := 0; i <= generator.spanCount; i++ {
+		row := &maniaPattern{}
+
+		if !(ignoreHead && startTime == generator.startTime) {
+			for j := 0; j < noteCount; j++ {
+				column = generator.findAvailableColumn(column, generator.randomStart, keyCount, nil, func(column int) bool {
+					return column != holdColumn
+				}, row)
+				generator.addPathNote(row, column, startTime, startTime)
+			}
+		}
+
+		pattern.addPattern(row)
+		startTime += generator.segmentDuration
+	}
+
+	return pattern
+}
+
+// Notes get the sounds of their slider node, holds the ones of the whole slider
+func (generator *maniaObjectGenerator) addPathNote(pattern *maniaPattern, column int, startTime int, endTime int) {
+	note := maniaNote{
+		column:         column,
+		startTime:      float64(startTime),
+		endTime:        float64(endTime),
+		hitObjectIndex: generator.hitObjectIndex,
+		node:           -1,
+	}
+
+	if startTime == endTime {
+		note.node = generator.nodeAt(startTime)
+	}
+
+	pattern.add(note)
+}
+
+// Copy of an osu!standard map converted to osu!mania with keyCount columns, the way the client plays it.
+// A keyCount of 0 picks the key count the client uses without a key mod, which depends on
+// how many sliders and spinners there are and on overall difficulty.
+// Difficulty calculation treats the copy like a map made for osu!mania,
+// convert star ratings come from calling ManiaDifficulty on the original
+func (osuFile OsuFile) ConvertToMania(keyCount int) (OsuFile, error) {
+	if osuFile.General.Mode != PlaymodeOsu {
+		return osuFile, ErrUnsupportedConversion
+	}
+
+	notes, keyCount, err := osuFile.maniaObjects(keyCount)
+
+	if err != nil {
+		return osuFile, err
+	}
+
+	hitObjects := make([]HitObject, 0, len(notes))
+
+	osuFile.HitObjects.CountNormal = 0
+	osuFile.HitObjects.CountSlider = 0
+	osuFile.HitObjects.CountSpinner = 0
+	osuFile.HitObjects.CountHold = 0
+
+	for _, note := range notes {
+		source := &osuFile.HitObjects.List[note.hitObjectIndex]
+
+		hitObject := HitObject{
+			Type:              HitObjectTypeCircle,
+			Position:          Vec2{X: math.Floor((float64(note.column) + 0.5) * 512 / float64(keyCount)), Y: 192},
+			Time:              note.startTime,
+			HitSound:          source.HitSound,
+			SampleSet:         source.SampleSet,
+			SampleSetAddition: source.SampleSetAddition,
+			CustomSampleSet:   source.CustomSampleSet,
+			Volume:            source.Volume,
+			SampleFile:        source.SampleFile,
+		}
+
+		//Notes on slider nodes keep the sounds of the node
+		if note.node >= 0 {
+			if note.node < len(source.SoundTypes) {
+				hitObject.HitSound = source.SoundTypes[note.node]
+			}
+
+			if note.node < len(source.SampleSets) {
+				hitObject.SampleSet = source.SampleSets[note.node]
+			}
+
+			if note.node < len(source.SampleSetAdditions) {
+				hitObject.SampleSetAddition = source.SampleSetAdditions[note.node]
+			}
+		}
+
+		if note.endTime != note.startTime {
+			hitObject.Type = HitObjectTypeHold
+			hitObject.EndTime = int32(note.endTime)
+
+			osuFile.HitObjects.CountHold++
+		} else {
+			osuFile.HitObjects.CountNormal++
+		}
+
+		hitObjects = append(hitObjects, hitObject)
+	}
+
+	osuFile.General.Mode = PlaymodeMania
+	osuFile.Difficulty.CircleSize = float64(keyCount)
+	osuFile.HitObjects.List = hitObjects
+
+	osuFile.computeDerivedValues(nil)
+
+	return osuFile, nil
+}
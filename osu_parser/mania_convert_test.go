@@ -0,0 +1,151 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func maniaColumns(maniaFile osu_parser.OsuFile) []int {
+	columns := []int{}
+	keyCount := maniaFile.Difficulty.CircleSize
+
+	for _, hitObject := range maniaFile.HitObjects.List {
+		columns = append(columns, int(hitObject.Position.X*keyCount/512))
+	}
+
+	return columns
+}
+
+func TestConvertToManiaStairs(t *testing.T) {
+	//Circles 90ms apart go up the columns one by one and wrap around, one 80ms later avoids the column before it.
+	//The last one is on the same spot after a pause, which mirrors the note before it.
+	//The map is easy enough for every other circle to get a single note on the column under it
+	osuText := "osu file format v14\n\n[General]\nMode: 0\n\n[Difficulty]\nHPDrainRate:0\nApproachRate:0\nOverallDifficulty:0\n\n" +
+		"[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n" +
+		"64,192,1000,1,0,0:0:0:0:\n" +
+		"200,192,1090,1,0,0:0:0:0:\n" +
+		"200,192,1180,1,0,0:0:0:0:\n" +
+		"200,192,1270,1,0,0:0:0:0:\n" +
+		"200,192,1360,1,0,0:0:0:0:\n" +
+		"448,192,1440,1,0,0:0:0:0:\n" +
+		"448,192,3000,1,0,0:0:0:0:\n"
+
+	osuFile, _ := osu_parser.ParseText(osuText)
+
+	mania, err := osuFile.ConvertToMania(4)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{0, 1, 2, 3, 0, 3, 0}
+
+	if columns := maniaColumns(mania); fmt.Sprint(columns) != fmt.Sprint(expected) {
+		t.Fatalf("expected columns %v, got %v", expected, columns)
+	}
+
+	if mania.General.Mode != osu_parser.PlaymodeMania || mania.Difficulty.CircleSize != 4 || mania.HitObjects.CountNormal != 7 || mania.HitObjects.List[1].Time != 1090 {
+		t.Fatalf("wrong converted map: %+v", mania.HitObjects)
+	}
+}
+
+func TestConvertToManiaHolds(t *testing.T) {
+	//On 1 key sliders and spinners become holds, spinners shorter than 100ms notes
+	osuText := "osu file format v14\n\n[General]\nMode: 0\n\n[Difficulty]\nSliderMultiplier:1\n\n" +
+		"[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n" +
+		"256,192,0,1,2,0:0:0:0:\n" +
+		"100,100,1000,2,8,L|200:100,1,100\n" +
+		"256,192,2000,8,0,3000,0:0:0:0:\n" +
+		"256,192,4000,8,0,4050,0:0:0:0:\n"
+
+	osuFile, _ := osu_parser.ParseText(osuText)
+
+	mania, err := osuFile.ConvertToMania(1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		hitObjectType osu_parser.HitObjectType
+		time          float64
+		endTime       int32
+		hitSound      osu_parser.HitSoundType
+	}{
+		{osu_parser.HitObjectTypeCircle, 0, 0, osu_parser.HitSoundTypeWhistle},
+		//100 osu!pixels at 100 osu!pixels per 500ms beat
+		{osu_parser.HitObjectTypeHold, 1000, 1500, osu_parser.HitSoundTypeClap},
+		{osu_parser.HitObjectTypeHold, 2000, 3000, 0},
+		{osu_parser.HitObjectTypeCircle, 4000, 0, 0},
+	}
+
+	if len(mania.HitObjects.List) != len(expected) {
+		t.Fatalf("expected %d objects, got %+v", len(expected), mania.HitObjects.List)
+	}
+
+	for i, want := range expected {
+		got := mania.HitObjects.List[i]
+
+		if got.Type != want.hitObjectType || got.Time != want.time || got.EndTime != want.endTime || got.HitSound != want.hitSound {
+			t.Errorf("object %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if mania.HitObjects.CountHold != 2 || mania.HitObjects.CountNormal != 2 {
+		t.Fatalf("wrong object counts: %d notes, %d holds", mania.HitObjects.CountNormal, mania.HitObjects.CountHold)
+	}
+}
+
+func TestConvertToManiaKeyCount(t *testing.T) {
+	osuFile, err := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//29 of 63 objects are sliders or spinners, that's more than 30% and less than 60% so overall difficulty 6 gives 7 keys
+	automatic, err := osuFile.ConvertToMania(0)
+
+	if err != nil || automatic.Difficulty.CircleSize != 7 {
+		t.Fatalf("expected 7 keys, got %v (%v)", automatic.Difficulty.CircleSize, err)
+	}
+
+	for _, keyCount := range []int{1, 4, 7, 8, 10} {
+		mania, err := osuFile.ConvertToMania(keyCount)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, column := range maniaColumns(mania) {
+			if column < 0 || column >= keyCount {
+				t.Fatalf("%d keys: object %d on column %d", keyCount, i, column)
+			}
+		}
+
+		//Every object gives at least one note
+		if len(mania.HitObjects.List) < len(osuFile.HitObjects.List) {
+			t.Fatalf("%d keys: only %d notes", keyCount, len(mania.HitObjects.List))
+		}
+
+		//The converter always picks the same columns
+		again, _ := osuFile.ConvertToMania(keyCount)
+
+		if fmt.Sprint(maniaColumns(again)) != fmt.Sprint(maniaColumns(mania)) {
+			t.Fatalf("%d keys: conversion isn't the same twice", keyCount)
+		}
+	}
+
+	for _, keyCount := range []int{-1, 11} {
+		if _, err := osuFile.ConvertToMania(keyCount); !errors.Is(err, osu_parser.ErrUnsupportedConversion) {
+			t.Fatalf("%d keys: expected ErrUnsupportedConversion, got %v", keyCount, err)
+		}
+	}
+
+	if _, err := automatic.ConvertToMania(4); !errors.Is(err, osu_parser.ErrUnsupportedConversion) {
+		t.Fatalf("expected ErrUnsupportedConversion, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package osu_parser
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	maniaDifficultyMultiplier = 0.018
+
+	individualDecayBase = 0.125
+	overallDecayBase    = 0.3
+	//Releasing holds this far apart from each other is half as hard as releasing them on their own
+	releaseThreshold = 30.0
+)
+
+type ManiaDifficultyAttributes struct {
+	StarRating float64
+
+	KeyCount int
+	//Hit window of a 300 in milliseconds, either side of the note
+	GreatHitWindow float64
+	MaxCombo       int
+}
+
+// A note or hold in its column
+type maniaNote struct {
+	column    int
+	startTime float64
+	endTime   float64
+
+	//Which object the note came from, converted slider nodes also keep their node, -1 otherwise
+	hitObjectIndex int
+	node           int
+}
+
+// Key count of an osu!mania map, which it keeps in CircleSize
+func maniaKeyCount(circleSize float64) int {
+	return int(math.Max(1, math.Round(circleSize)))
+}
+
+// Column of an object, the playfield width is split evenly between the columns
+func maniaColumn(x float64, keyCount int) int {
+	column := int(math.Floor(x * float64(keyCount) / 512))
+
+	return max(0, min(keyCount-1, column))
+}
+
+// Star rating and difficulty attributes for an osu!mania map, or an osu!standard map converted to osu!mania
+// with the key count of the key mod, or the one the client picks without one.
+// Mods changing the map are applied with ApplyMods, so a map which already had them applied should be given ModsNone
+func (osuFile *OsuFile) ManiaDifficulty(mods Mods) (ManiaDifficultyAttributes, error) {
+	attributes := ManiaDifficultyAttributes{}
+
+	if osuFile.General.Mode != PlaymodeMania && osuFile.General.Mode != PlaymodeOsu {
+		return attributes, ErrUnsupportedConversion
+	}
+
+	if err := mods.Validate(); err != nil {
+		return attributes, err
+	}
+
+	if err := checkHitObjectTimes(osuFile.HitObjects.List); err != nil {
+		return attributes, err
+	}
+
+	mania := *osuFile
+	//Converts only have two hit windows, depending on overall difficulty
+	hitWindow := 47.0
+
+	if osuFile.General.Mode == PlaymodeOsu {
+		//Maps are converted at normal speed, mods apply to the converted map
+		converted, err := osuFile.ConvertToMania(mods.keyCount())
+
+		if err != nil {
+			return attributes, err
+		}
+
+		mania = converted
+
+		if math.RoundToEven(osuFile.Difficulty.OverallDifficulty) > 4 {
+			hitWindow = 34
+		}
+	} else {
+		hitWindow = 34 + 3*math.Max(0, math.Min(10, 10-osuFile.Difficulty.OverallDifficulty))
+	}
+
+	modded, err := mania.ApplyMods(mods)
+
+	if err != nil {
+		return attributes, err
+	}
+
+	attributes = modded.maniaDifficulty()
+	attributes.GreatHitWindow = maniaGreatHitWindow(hitWindow, mods)
+
+	return attributes, nil
+}
+
+// osu!mania scales hit windows instead of overall difficulty, rounded up to whole milliseconds.
+// They stay the same length in real time with a clock rate
+func maniaGreatHitWindow(hitWindow float64, mods Mods) float64 {
+	switch {
+	case mods.Has(ModsHardRock):
+		hitWindow /= 1.4
+	case mods.Has(ModsEasy):
+		hitWindow *= 1.4
+	}
+
+	return math.Ceil(hitWindow)
+}
+
+func (osuFile *OsuFile) maniaDifficulty() ManiaDifficultyAttributes {
+	attributes := ManiaDifficultyAttributes{}
+
+	attributes.KeyCount = maniaKeyCount(osuFile.Difficulty.CircleSize)
+	attributes.MaxCombo = osuFile.MaxCombo()
+
+	notes := []maniaNote{}
+
+	for i := range osuFile.HitObjects.List {
+		hitObject := &osuFile.HitObjects.List[i]
+
+		note := maniaNote{
+			column:    maniaColumn(hitObject.Position.X, attributes.KeyCount),
+			startTime: hitObject.Time,
+			endTime:   hitObject.Time,
+
+			hitObjectIndex: i,
+			node:           -1,
+		}
+
+		if hitObject.Type == HitObjectTypeHold {
+			note.endTime = float64(hitObject.EndTime)
+		}
+
+		notes = append(notes, note)
+	}
+
+	attributes.StarRating = maniaStarRating(notes, attributes.KeyCount)
+
+	return attributes
+}
+
+func maniaStarRating(notes []maniaNote, keyCount int) float64 {
+	if len(notes) == 0 {
+		return 0
+	}
+
+	//Objects are ordered by their rounded start time, like osu!stable does
+	sorted := append([]maniaNote{}, notes...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return math.Round(sorted[i].startTime) < math.Round(sorted[j].startTime)
+	})
+
+	peaks := strainPeaks{}
+	strain := newManiaStrainSkill(keyCount)
+
+	//The first note doesn't have anything before it, so it doesn't add any strain
+	for i := 1; i < len(sorted); i++ {
+		deltaTime := sorted[i].startTime - sorted[i-1].startTime
+
+		peaks.process(i-1, sorted[i].startTime, func() float64 {
+			return strain.strainValueOf(sorted[i], deltaTime)
+		}, func(time float64) float64 {
+			return strain.initialStrain(time - sorted[i-1].startTime)
+		})
+	}
+
+	return weightedStrainSum(peaks.all(), 0.9) * maniaDifficultyMultiplier
+}
+
+// Strain of every column on its own, plus strain of the whole map
+type maniaStrainSkill struct {
+	startTimes        []float64
+	endTimes          []float64
+	individualStrains []float64
+
+	individualStrain float64
+	overallStrain    float64
+}
+
+func newManiaStrainSkill(keyCount int) *maniaStrainSkill {
+	return &maniaStrainSkill{
+		startTimes:        make([]float64, keyCount),
+		endTimes:          make([]float64, keyCount),
+		individualStrains: make([]float64, keyCount),
+		overallStrain:     1,
+	}
+}
+
+func (skill *maniaStrainSkill) strainValueOf(note maniaNote, deltaTime float64) float64 {
+	isOverlapping := false
+
+	//Closest release of another column, the hold's own length is the furthest it can be
+	closestEndTime := math.Abs(note.endTime - note.startTime)
+	//Everything is harder while something else is held
+	holdFactor := 1.0
+	//Holds are harder to release while other holds go on
+	holdAddition := 0.0
+
+	for _, endTime := range skill.endTimes {
+		isOverlapping = isOverlapping || (endTime-note.startTime > 1 && note.endTime-endTime > 1)
+
+		if endTime-note.endTime > 1 {
+			holdFactor = 1.25
+		}
+
+		closestEndTime = math.Min(closestEndTime, math.Abs(note.endTime-endTime))
+	}
+
+	//Releasing several holds at once is as easy as releasing one
+	if isOverlapping {
+		holdAddition = 1 / (1 + math.Exp(0.5*(releaseThreshold-closestEndTime)))
+	}
+
+	column := note.column
+
+	skill.individualStrains[column] *= strainDecay(individualDecayBase, note.startTime-skill.startTimes[column])
+	skill.individualStrains[column] += 2 * holdFactor
+
+	//Chords are as hard as their hardest column
+	if deltaTime <= 1 {
+		skill.individualStrain = math.Max(skill.individualStrain, skill.individualStrains[column])
+	} else {
+		skill.individualStrain = skill.individualStrains[column]
+	}
+
+	skill.overallStrain *= strainDecay(overallDecayBase, deltaTime)
+	skill.overallStrain += (1 + holdAddition) * holdFactor
+
+	skill.startTimes[column] = note.startTime
+	skill.endTimes[column] = note.endTime
+
+	return skill.individualStrain + skill.overallStrain
+}
+
+func (skill *maniaStrainSkill) initialStrain(sinceLastNote float64) float64 {
+	return skill.individualStrain*strainDecay(individualDecayBase, sinceLastNote) + skill.overallStrain*strainDecay(overallDecayBase, sinceLastNote)
+}
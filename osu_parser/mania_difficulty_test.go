@@ -0,0 +1,124 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+// 4 key map with a note every interval milliseconds, going through the columns in order
+func maniaTestMap(interval int, holds bool) string {
+	text := "osu file format v14\n\n[General]\nMode: 3\n\n[Difficulty]\nCircleSize:4\nOverallDifficulty:8\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n"
+
+	for i := 0; i < 128; i++ {
+		x := 64 + (i%4)*128
+
+		if holds {
+			text += fmt.Sprintf("%d,192,%d,128,0,%d:0:0:0:0:\n", x, i*interval, i*interval+interval*3)
+		} else {
+			text += fmt.Sprintf("%d,192,%d,1,0,0:0:0:0:\n", x, i*interval)
+		}
+	}
+
+	return text
+}
+
+func TestManiaDifficulty(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(maniaTestMap(100, false))
+
+	attributes, err := parsedOsuFile.ManiaDifficulty(osu_parser.ModsNone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(attributes.StarRating-2.1353) > 0.0001 || attributes.KeyCount != 4 || attributes.GreatHitWindow != 40 || attributes.MaxCombo != 128 {
+		t.Fatalf("wrong attributes: %+v", attributes)
+	}
+
+	fast, _ := osu_parser.ParseText(maniaTestMap(50, false))
+	holds, _ := osu_parser.ParseText(maniaTestMap(100, true))
+
+	fastAttributes, _ := fast.ManiaDifficulty(osu_parser.ModsNone)
+	holdAttributes, _ := holds.ManiaDifficulty(osu_parser.ModsNone)
+
+	if !(fastAttributes.StarRating > attributes.StarRating) {
+		t.Fatalf("faster notes aren't harder: %f against %f", fastAttributes.StarRating, attributes.StarRating)
+	}
+
+	//Overlapping holds make everything harder
+	if !(holdAttributes.StarRating > attributes.StarRating) {
+		t.Fatalf("holds aren't harder: %f against %f", holdAttributes.StarRating, attributes.StarRating)
+	}
+}
+
+func TestManiaDifficultyHitWindow(t *testing.T) {
+	//Hit windows are rounded up to whole milliseconds
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nMode: 3\n\n[Difficulty]\nCircleSize:4\nOverallDifficulty:7.5\n")
+
+	if attributes, _ := parsedOsuFile.ManiaDifficulty(osu_parser.ModsNone); attributes.GreatHitWindow != 42 {
+		t.Fatalf("expected a hit window of 42, got %f", attributes.GreatHitWindow)
+	}
+}
+
+func TestManiaDifficultyConvert(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	attributes, err := parsedOsuFile.ManiaDifficulty(osu_parser.ModsNone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mania, _ := parsedOsuFile.ConvertToMania(0)
+	native, _ := mania.ManiaDifficulty(osu_parser.ModsNone)
+
+	//Overall difficulty 6 rounds to more than 4, which gives converts the tighter of their two hit windows
+	if attributes.StarRating != native.StarRating || attributes.KeyCount != 7 || attributes.MaxCombo != native.MaxCombo || attributes.GreatHitWindow != 34 {
+		t.Fatalf("wrong attributes: %+v against %+v", attributes, native)
+	}
+
+	catch, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nMode: 2\n")
+
+	if _, err := catch.ManiaDifficulty(osu_parser.ModsNone); !errors.Is(err, osu_parser.ErrUnsupportedConversion) {
+		t.Fatalf("expected ErrUnsupportedConversion, got %v", err)
+	}
+}
+
+func TestManiaDifficultyMods(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(maniaTestMap(100, false))
+
+	attributes, _ := parsedOsuFile.ManiaDifficulty(osu_parser.ModsNone)
+	fastAttributes, err := parsedOsuFile.ManiaDifficulty(osu_parser.ModsDoubleTime)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Hit windows stay the same in real time
+	if !(fastAttributes.StarRating > attributes.StarRating) || fastAttributes.GreatHitWindow != attributes.GreatHitWindow || fastAttributes.MaxCombo != attributes.MaxCombo {
+		t.Fatalf("DoubleTime didn't make the map harder: %+v against %+v", fastAttributes, attributes)
+	}
+
+	//Overall difficulty 8 gives 40, which HardRock divides by 1.4
+	if hardRockAttributes, _ := parsedOsuFile.ManiaDifficulty(osu_parser.ModsHardRock); hardRockAttributes.GreatHitWindow != 29 || hardRockAttributes.KeyCount != 4 {
+		t.Fatalf("wrong HardRock attributes: %+v", hardRockAttributes)
+	}
+
+	converted, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	keyAttributes, err := converted.ManiaDifficulty(osu_parser.ModsKey4)
+	mania, _ := converted.ConvertToMania(4)
+	native, _ := mania.ManiaDifficulty(osu_parser.ModsNone)
+
+	if err != nil || keyAttributes.KeyCount != 4 || keyAttributes.StarRating != native.StarRating {
+		t.Fatalf("key mod not used for the convert: %+v against %+v (%v)", keyAttributes, native, err)
+	}
+
+	if _, err := converted.ManiaDifficulty(osu_parser.ModsKey4 | osu_parser.ModsKey5); !errors.Is(err, osu_parser.ErrIncompatibleMods) {
+		t.Fatalf("expected ErrIncompatibleMods, got %v", err)
+	}
+}
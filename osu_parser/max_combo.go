@@ -0,0 +1,177 @@
+package osu_parser
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	ErrUnsupportedConversion = errors.New("unsupported conversion")
+)
+
+const (
+	//Taiko scrolls 1.4 times as fast as osu!standard sliders move
+	taikoVelocityMultiplier = 1.4
+)
+
+// Highest combo reachable on the map in its own mode
+func (osuFile *OsuFile) MaxCombo() int {
+	maxCombo, _ := osuFile.MaxComboFor(osuFile.General.Mode)
+
+	return maxCombo
+}
+
+// Highest combo reachable on the map played in mode, which converts osu!standard maps to the other modes.
+// osu!mania converts use the key count the client picks, converting anything that's not osu!standard isn't supported
+func (osuFile *OsuFile) MaxComboFor(mode Playmode) (int, error) {
+	converted := mode != osuFile.General.Mode
+
+	if converted && osuFile.General.Mode != PlaymodeOsu {
+		return 0, ErrUnsupportedConversion
+	}
+
+	//Holds depend on the patterns the converter comes up with
+	if converted && mode == PlaymodeMania {
+		mania, err := osuFile.ConvertToMania(0)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return mania.MaxCombo(), nil
+	}
+
+	maxCombo := 0
+
+	for i := range osuFile.HitObjects.List {
+		hitObject := &osuFile.HitObjects.List[i]
+
+		switch mode {
+		case PlaymodeOsu:
+			maxCombo += osuFile.sliderCombo(hitObject, true)
+		case PlaymodeTaiko:
+			maxCombo += osuFile.taikoCombo(hitObject, converted)
+		case PlaymodeCatch:
+			maxCombo += osuFile.sliderCombo(hitObject, false)
+		case PlaymodeMania:
+			maxCombo += osuFile.maniaCombo(hitObject)
+		}
+	}
+
+	return maxCombo, nil
+}
+
+// Combo of an object in osu!standard and osu!catch, where sliders give combo for their head, ticks, repeats and tail.
+// Spinners give combo in osu!standard, bananas don't in osu!catch
+func (osuFile *OsuFile) sliderCombo(hitObject *HitObject, standard bool) int {
+	switch hitObject.Type {
+	case HitObjectTypeSlider:
+		if !standard {
+			return len(osuFile.catchNestedObjects(hitObject))
+		}
+
+		combo := 0
+
+		for _, event := range osuFile.SliderTiming(hitObject).NestedObjects() {
+			if event.Type != SliderEventLegacyLastTick {
+				combo++
+			}
+		}
+
+		return combo
+	case HitObjectTypeSpinner:
+		if standard {
+			return 1
+		}
+
+		return 0
+	}
+
+	return 1
+}
+
+// Only hits give combo in osu!taiko, drumrolls and swells don't.
+// Converted sliders short enough to be played as hits turn into a hit on every tick
+func (osuFile *OsuFile) taikoCombo(hitObject *HitObject, converted bool) int {
+	switch hitObject.Type {
+	case HitObjectTypeSpinner:
+		return 0
+	case HitObjectTypeSlider:
+		if !converted {
+			return 0
+		}
+
+		duration, tickSpacing, convertsToHits := osuFile.taikoSliderConversion(hitObject)
+
+		if !convertsToHits {
+			return 0
+		}
+
+		//One hit on the head and every tick after, with an eighth of a tick of leeway at the end
+		return int(math.Floor((duration+tickSpacing/8)/tickSpacing)) + 1
+	}
+
+	return 1
+}
+
+// Works out if a converted slider turns into a drumroll or into hits like the game does,
+// including its floating point quirks
+func (osuFile *OsuFile) taikoSliderConversion(hitObject *HitObject) (float64, float64, bool) {
+	timing := osuFile.SliderTiming(hitObject)
+
+	spans := float64(timing.SpanCount)
+	distance := hitObject.declaredLength() * spans * taikoVelocityMultiplier
+
+	timingPointBeatLength := osuFile.TimingPoints.BeatLengthAt(hitObject.Time)
+	beatLength := timingPointBeatLength / osuFile.TimingPoints.SliderVelocityAt(hitObject.Time)
+
+	sliderMultiplier := osuFile.Difficulty.SliderMultiplier
+	tickRate := osuFile.Difficulty.SliderTickRate
+
+	if !(sliderMultiplier > 0) {
+		sliderMultiplier = defaultSliderMultiplier
+	}
+
+	if !(tickRate > 0) {
+		tickRate = defaultSliderTickRate
+	}
+
+	scoringPointDistance := baseScoringDistance * (sliderMultiplier * taikoVelocityMultiplier) / tickRate
+	taikoVelocity := scoringPointDistance * tickRate
+	duration := float64(int64(distance / taikoVelocity * beatLength))
+
+	osuVelocity := taikoVelocity * (1000 / beatLength)
+
+	//Only older maps use the slider velocity adjusted beat length for the conversion
+	if osuFile.Version >= 8 {
+		beatLength = timingPointBeatLength
+	}
+
+	tickSpacing := math.Min(beatLength/tickRate, duration/spans)
+
+	return duration, tickSpacing, tickSpacing > 0 && distance/osuVelocity*1000 < 2*beatLength
+}
+
+// Notes give 1 combo in osu!mania, holds 1 for their head, 1 for their tail and 1 for every tick in between
+func (osuFile *OsuFile) maniaCombo(hitObject *HitObject) int {
+	if hitObject.Type != HitObjectTypeHold {
+		return 1
+	}
+
+	tickRate := osuFile.Difficulty.SliderTickRate
+
+	if !(tickRate > 0) {
+		tickRate = defaultSliderTickRate
+	}
+
+	tickSpacing := osuFile.TimingPoints.BeatLengthAt(hitObject.Time) / tickRate
+
+	//Ticks go from one tick after the start up to one tick before the end
+	tickRange := float64(hitObject.EndTime) - tickSpacing - (hitObject.Time + tickSpacing)
+
+	if !(tickRange >= 0) {
+		return 2
+	}
+
+	return 2 + int(math.Floor(tickRange/tickSpacing)) + 1
+}
@@ -0,0 +1,83 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+const maxComboTestText = `osu file format v14
+
+[Difficulty]
+SliderMultiplier:1
+SliderTickRate:1
+
+[TimingPoints]
+0,500,4,2,0,100,1,0
+
+[HitObjects]
+0,0,0,1,0
+0,0,1000,2,0,L|200:0,2,200
+256,192,4000,8,0,5000
+0,0,6000,2,0,L|50:0,1,50
+`
+
+func TestMaxCombo(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(maxComboTestText)
+
+	if parsedOsuFile.MaxCombo() != 9 {
+		t.Fatalf("expected max combo 9, got %d", parsedOsuFile.MaxCombo())
+	}
+
+	//No bananas, and the long slider stays a drumroll while the short one turns into two hits
+	expected := map[osu_parser.Playmode]int{
+		osu_parser.PlaymodeCatch: 8,
+		osu_parser.PlaymodeTaiko: 3,
+	}
+
+	for mode, want := range expected {
+		maxCombo, err := parsedOsuFile.MaxComboFor(mode)
+
+		if err != nil || maxCombo != want {
+			t.Fatalf("mode %d: expected max combo %d, got %d (%v)", mode, want, maxCombo, err)
+		}
+	}
+}
+
+func TestMaxComboManiaConvert(t *testing.T) {
+	//4 keys, the slider turns into two holds of 5 and 3 combo, the spinner into a hold of 3
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[Difficulty]\nSliderMultiplier:1\nSliderTickRate:1\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n0,0,0,1,0\n0,0,1000,2,0,L|200:0,2,200\n256,192,4000,8,0,5000\n")
+
+	maxCombo, err := parsedOsuFile.MaxComboFor(osu_parser.PlaymodeMania)
+
+	if err != nil || maxCombo != 12 {
+		t.Fatalf("expected max combo 12, got %d (%v)", maxCombo, err)
+	}
+
+	mania, _ := parsedOsuFile.ConvertToMania(0)
+
+	if mania.MaxCombo() != maxCombo {
+		t.Fatalf("converted map has max combo %d, expected %d", mania.MaxCombo(), maxCombo)
+	}
+}
+
+func TestMaxComboNative(t *testing.T) {
+	//A hold with one tick in the middle
+	mania, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nMode: 3\n\n[Difficulty]\nSliderTickRate:1\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n64,192,0,1,0,0:0:0:0:\n64,192,0,128,0,1000:0:0:0:0:\n")
+
+	if mania.MaxCombo() != 4 {
+		t.Fatalf("expected mania max combo 4, got %d", mania.MaxCombo())
+	}
+
+	//Drumrolls and swells don't count in native taiko maps
+	taiko, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nMode: 1\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n0,0,0,1,0\n0,0,6000,2,0,L|50:0,1,50\n256,192,7000,8,0,8000\n")
+
+	if taiko.MaxCombo() != 1 {
+		t.Fatalf("expected taiko max combo 1, got %d", taiko.MaxCombo())
+	}
+
+	if _, err := taiko.MaxComboFor(osu_parser.PlaymodeOsu); !errors.Is(err, osu_parser.ErrUnsupportedConversion) {
+		t.Fatal("converted a taiko map")
+	}
+}
@@ -0,0 +1,315 @@
+package osu_parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	ErrIncompatibleMods = errors.New("incompatible mods")
+)
+
+// Mods a score was set with, using the same values as the client
+type Mods int32
+
+const (
+	ModsNone        Mods = 0
+	ModsNoFail      Mods = 1
+	ModsEasy        Mods = 2
+	ModsTouchDevice Mods = 4
+	ModsHidden      Mods = 8
+	ModsHardRock    Mods = 16
+	ModsSuddenDeath Mods = 32
+	ModsDoubleTime  Mods = 64
+	ModsRelax       Mods = 128
+	ModsHalfTime    Mods = 256
+	//Always set together with ModsDoubleTime
+	ModsNightcore  Mods = 512
+	ModsFlashlight Mods = 1024
+	ModsAutoplay   Mods = 2048
+	ModsSpunOut    Mods = 4096
+	ModsAutopilot  Mods = 8192
+	//Always set together with ModsSuddenDeath
+	ModsPerfect Mods = 16384
+	ModsKey4    Mods = 32768
+	ModsKey5    Mods = 65536
+	ModsKey6    Mods = 131072
+	ModsKey7    Mods = 262144
+	ModsKey8    Mods = 524288
+	ModsFadeIn  Mods = 1048576
+	ModsRandom  Mods = 2097152
+	ModsCinema  Mods = 4194304
+	ModsTarget  Mods = 8388608
+	ModsKey9    Mods = 16777216
+	ModsKeyCoop Mods = 33554432
+	ModsKey1    Mods = 67108864
+	ModsKey3    Mods = 134217728
+	ModsKey2    Mods = 268435456
+	ModsScoreV2 Mods = 536870912
+	ModsMirror  Mods = 1073741824
+)
+
+var modNames = []struct {
+	mod     Mods
+	name    string
+	acronym string
+}{
+	{ModsNoFail, "NoFail", "NF"},
+	{ModsEasy, "Easy", "EZ"},
+	{ModsTouchDevice, "TouchDevice", "TD"},
+	{ModsHidden, "Hidden", "HD"},
+	{ModsHardRock, "HardRock", "HR"},
+	{ModsSuddenDeath, "SuddenDeath", "SD"},
+	{ModsDoubleTime, "DoubleTime", "DT"},
+	{ModsRelax, "Relax", "RX"},
+	{ModsHalfTime, "HalfTime", "HT"},
+	{ModsNightcore, "Nightcore", "NC"},
+	{ModsFlashlight, "Flashlight", "FL"},
+	{ModsAutoplay, "Autoplay", "AT"},
+	{ModsSpunOut, "SpunOut", "SO"},
+	{ModsAutopilot, "Autopilot", "AP"},
+	{ModsPerfect, "Perfect", "PF"},
+	{ModsKey4, "Key4", "4K"},
+	{ModsKey5, "Key5", "5K"},
+	{ModsKey6, "Key6", "6K"},
+	{ModsKey7, "Key7", "7K"},
+	{ModsKey8, "Key8", "8K"},
+	{ModsFadeIn, "FadeIn", "FI"},
+	{ModsRandom, "Random", "RD"},
+	{ModsCinema, "Cinema", "CN"},
+	{ModsTarget, "Target", "TP"},
+	{ModsKey9, "Key9", "9K"},
+	{ModsKeyCoop, "KeyCoop", "2P"},
+	{ModsKey1, "Key1", "1K"},
+	{ModsKey3, "Key3", "3K"},
+	{ModsKey2, "Key2", "2K"},
+	{ModsScoreV2, "ScoreV2", "V2"},
+	{ModsMirror, "Mirror", "MR"},
+}
+
+// Mods which can't be enabled together
+var incompatibleMods = [][2]Mods{
+	{ModsEasy, ModsHardRock},
+	{ModsDoubleTime, ModsHalfTime},
+	{ModsNoFail, ModsSuddenDeath},
+	{ModsNoFail, ModsRelax},
+	{ModsNoFail, ModsAutopilot},
+	{ModsSuddenDeath, ModsRelax},
+	{ModsSuddenDeath, ModsAutopilot},
+	{ModsSuddenDeath, ModsAutoplay},
+	{ModsRelax, ModsAutopilot},
+	{ModsRelax, ModsAutoplay},
+	{ModsAutopilot, ModsAutoplay},
+	{ModsAutopilot, ModsSpunOut},
+	{ModsHidden, ModsFadeIn},
+}
+
+// Mods which are only ever enabled together with another one
+var requiredMods = [][2]Mods{
+	{ModsNightcore, ModsDoubleTime},
+	{ModsPerfect, ModsSuddenDeath},
+	{ModsCinema, ModsAutoplay},
+}
+
+const (
+	keyMods = ModsKey1 | ModsKey2 | ModsKey3 | ModsKey4 | ModsKey5 | ModsKey6 | ModsKey7 | ModsKey8 | ModsKey9 | ModsKeyCoop
+)
+
+// Whether all of the given mods are enabled
+func (mods Mods) Has(other Mods) bool {
+	return mods&other == other
+}
+
+// Full names of the enabled mods, like "Hidden"
+func (mods Mods) Names() []string {
+	names := []string{}
+
+	for _, mod := range modNames {
+		if mods.Has(mod.mod) {
+			names = append(names, mod.name)
+		}
+	}
+
+	return names
+}
+
+// Acronyms of the enabled mods, like "HD"
+func (mods Mods) Acronyms() []string {
+	acronyms := []string{}
+
+	for _, mod := range modNames {
+		if mods.Has(mod.mod) {
+			acronyms = append(acronyms, mod.acronym)
+		}
+	}
+
+	return acronyms
+}
+
+// The mods the way the client shows them, like "HDNC". Mods implied by others are left out
+func (mods Mods) String() string {
+	if mods.Has(ModsNightcore) {
+		mods &^= ModsDoubleTime
+	}
+
+	if mods.Has(ModsPerfect) {
+		mods &^= ModsSuddenDeath
+	}
+
+	if mods == ModsNone {
+		return "NM"
+	}
+
+	return strings.Join(mods.Acronyms(), "")
+}
+
+// Checks that the mods could've been enabled together in the client
+func (mods Mods) Validate() error {
+	for _, pair := range incompatibleMods {
+		if mods.Has(pair[0] | pair[1]) {
+			return fmt.Errorf("%w: %s and %s", ErrIncompatibleMods, pair[0], pair[1])
+		}
+	}
+
+	for _, pair := range requiredMods {
+		if mods.Has(pair[0]) && !mods.Has(pair[1]) {
+			return fmt.Errorf("%w: %s without %s", ErrIncompatibleMods, pair[0], pair[1])
+		}
+	}
+
+	//Only one key count at a time
+	if keys := mods & keyMods; keys&(keys-1) != 0 {
+		return fmt.Errorf("%w: %s", ErrIncompatibleMods, keys)
+	}
+
+	return nil
+}
+
+// Key count osu!standard maps get converted to, 0 lets the client pick
+func (mods Mods) keyCount() int {
+	for i, keyMod := range []Mods{ModsKey1, ModsKey2, ModsKey3, ModsKey4, ModsKey5, ModsKey6, ModsKey7, ModsKey8, ModsKey9} {
+		if mods.Has(keyMod) {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// How much faster the map plays
+func (mods Mods) ClockRate() float64 {
+	switch {
+	case mods.Has(ModsDoubleTime), mods.Has(ModsNightcore):
+		return 1.5
+	case mods.Has(ModsHalfTime):
+		return 0.75
+	}
+
+	return 1
+}
+
+// Copy of the map the way it plays with mods. HardRock and Easy scale the difficulty settings,
+// HardRock flips osu!standard maps vertically, DoubleTime and HalfTime change every time in the map.
+// With a clock rate the approach rate and overall difficulty are changed to the ones which play the same
+// at normal speed, so difficulty calculation works on the copy like on any other map.
+// Slices which don't need to change are shared with the original
+func (osuFile OsuFile) ApplyMods(mods Mods) (OsuFile, error) {
+	if err := mods.Validate(); err != nil {
+		return osuFile, err
+	}
+
+	difficulty := &osuFile.Difficulty
+	mania := osuFile.General.Mode == PlaymodeMania
+
+	switch {
+	case mods.Has(ModsHardRock):
+		//osu!mania keeps its key count in CircleSize
+		if !mania {
+			difficulty.CircleSize = math.Min(10, difficulty.CircleSize*1.3)
+		}
+
+		difficulty.ApproachRate = math.Min(10, difficulty.ApproachRate*1.4)
+		difficulty.OverallDifficulty = math.Min(10, difficulty.OverallDifficulty*1.4)
+		difficulty.HPDrainRate = math.Min(10, difficulty.HPDrainRate*1.4)
+	case mods.Has(ModsEasy):
+		if !mania {
+			difficulty.CircleSize *= 0.5
+		}
+
+		difficulty.ApproachRate *= 0.5
+		difficulty.OverallDifficulty *= 0.5
+		difficulty.HPDrainRate *= 0.5
+	}
+
+	osuFile.HitObjects.List = append([]HitObject{}, osuFile.HitObjects.List...)
+	osuFile.TimingPoints.TimingPoints = append([]TimingPoint{}, osuFile.TimingPoints.TimingPoints...)
+	osuFile.Events.Events = append([]Event{}, osuFile.Events.Events...)
+
+	if mods.Has(ModsHardRock) && osuFile.General.Mode == PlaymodeOsu {
+		for i := range osuFile.HitObjects.List {
+			hitObject := &osuFile.HitObjects.List[i]
+
+			hitObject.Position.Y = 384 - hitObject.Position.Y
+			hitObject.SliderPoints = append([]Vec2{}, hitObject.SliderPoints...)
+
+			for j := range hitObject.SliderPoints {
+				hitObject.SliderPoints[j].Y = 384 - hitObject.SliderPoints[j].Y
+			}
+		}
+	}
+
+	if rate := mods.ClockRate(); rate != 1 {
+		osuFile.applyClockRate(rate)
+	}
+
+	osuFile.computeDerivedValues(nil)
+
+	return osuFile, nil
+}
+
+func (osuFile *OsuFile) applyClockRate(rate float64) {
+	for i := range osuFile.HitObjects.List {
+		hitObject := &osuFile.HitObjects.List[i]
+
+		hitObject.Time /= rate
+		hitObject.EndTime = int32(float64(hitObject.EndTime) / rate)
+	}
+
+	//Inherited timing points only change the slider velocity, which stays the same
+	for i := range osuFile.TimingPoints.TimingPoints {
+		timingPoint := &osuFile.TimingPoints.TimingPoints[i]
+
+		timingPoint.Offset /= rate
+
+		if !timingPoint.InheritedTimingPoint {
+			timingPoint.BeatLength /= rate
+		}
+	}
+
+	for i := range osuFile.Events.Events {
+		event := &osuFile.Events.Events[i]
+
+		event.EventTime = int32(float64(event.EventTime) / rate)
+		event.BreakTimeBegin = int32(float64(event.BreakTimeBegin) / rate)
+		event.BreakTimeEnd = int32(float64(event.BreakTimeEnd) / rate)
+	}
+
+	//-1 means there's no preview time
+	if osuFile.General.PreviewTime > 0 {
+		osuFile.General.PreviewTime = int32(float64(osuFile.General.PreviewTime) / rate)
+	}
+
+	difficulty := &osuFile.Difficulty
+
+	difficulty.ApproachRate = approachRateFromPreempt(difficulty.PreemptAtRate(rate))
+
+	//The hit windows scale differently with overall difficulty in every mode, osu!mania's don't change with the rate
+	switch osuFile.General.Mode {
+	case PlaymodeOsu:
+		difficulty.OverallDifficulty = (80 - (80-6*difficulty.OverallDifficulty)/rate) / 6
+	case PlaymodeTaiko:
+		difficulty.OverallDifficulty = (50 - (50-3*difficulty.OverallDifficulty)/rate) / 3
+	}
+}
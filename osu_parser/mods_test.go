@@ -0,0 +1,136 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestModsString(t *testing.T) {
+	tests := map[osu_parser.Mods]string{
+		osu_parser.ModsNone:                             "NM",
+		osu_parser.ModsHidden | osu_parser.ModsHardRock: "HDHR",
+		osu_parser.ModsHidden | osu_parser.ModsDoubleTime | osu_parser.ModsNightcore:    "HDNC",
+		osu_parser.ModsSuddenDeath | osu_parser.ModsPerfect | osu_parser.ModsFlashlight: "FLPF",
+		osu_parser.ModsKey7 | osu_parser.ModsMirror:                                     "7KMR",
+	}
+
+	for mods, want := range tests {
+		if mods.String() != want {
+			t.Errorf("expected %s, got %s", want, mods.String())
+		}
+	}
+
+	names := (osu_parser.ModsEasy | osu_parser.ModsHalfTime).Names()
+
+	if len(names) != 2 || names[0] != "Easy" || names[1] != "HalfTime" {
+		t.Fatalf("wrong names: %v", names)
+	}
+}
+
+func TestModsValidate(t *testing.T) {
+	valid := []osu_parser.Mods{
+		osu_parser.ModsNone,
+		osu_parser.ModsHidden | osu_parser.ModsDoubleTime | osu_parser.ModsNightcore | osu_parser.ModsFlashlight,
+		osu_parser.ModsSuddenDeath | osu_parser.ModsPerfect | osu_parser.ModsHardRock,
+		osu_parser.ModsKey4 | osu_parser.ModsFadeIn,
+	}
+
+	invalid := []osu_parser.Mods{
+		osu_parser.ModsEasy | osu_parser.ModsHardRock,
+		osu_parser.ModsDoubleTime | osu_parser.ModsHalfTime,
+		osu_parser.ModsNightcore,
+		osu_parser.ModsNoFail | osu_parser.ModsSuddenDeath,
+		osu_parser.ModsRelax | osu_parser.ModsAutopilot,
+		osu_parser.ModsKey4 | osu_parser.ModsKey7,
+	}
+
+	for _, mods := range valid {
+		if err := mods.Validate(); err != nil {
+			t.Errorf("%s: %v", mods, err)
+		}
+	}
+
+	for _, mods := range invalid {
+		if err := mods.Validate(); !errors.Is(err, osu_parser.ErrIncompatibleMods) {
+			t.Errorf("%s: expected ErrIncompatibleMods, got %v", mods, err)
+		}
+	}
+}
+
+func TestApplyModsHardRock(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	hardRock, err := parsedOsuFile.ApplyMods(osu_parser.ModsHardRock)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//CS5 OD6 AR8 HP6
+	if hardRock.Difficulty.CircleSize != 6.5 || hardRock.Difficulty.ApproachRate != 10 || math.Abs(hardRock.Difficulty.OverallDifficulty-8.4) > 1e-9 || math.Abs(hardRock.Difficulty.HPDrainRate-8.4) > 1e-9 {
+		t.Fatalf("wrong difficulty: %+v", hardRock.Difficulty)
+	}
+
+	for i, hitObject := range hardRock.HitObjects.List {
+		original := parsedOsuFile.HitObjects.List[i]
+
+		if hitObject.Position.Y != 384-original.Position.Y || hitObject.Time != original.Time {
+			t.Fatalf("object %d not flipped: %v from %v", i, hitObject.Position, original.Position)
+		}
+
+		for j := range hitObject.SliderPoints {
+			if hitObject.SliderPoints[j].Y != 384-original.SliderPoints[j].Y {
+				t.Fatalf("object %d slider point %d not flipped", i, j)
+			}
+		}
+	}
+
+	if _, err := parsedOsuFile.ApplyMods(osu_parser.ModsEasy | osu_parser.ModsHardRock); !errors.Is(err, osu_parser.ErrIncompatibleMods) {
+		t.Fatalf("expected ErrIncompatibleMods, got %v", err)
+	}
+}
+
+func TestApplyModsDoubleTime(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+	originalTime := parsedOsuFile.HitObjects.List[10].Time
+
+	doubleTime, err := parsedOsuFile.ApplyMods(osu_parser.ModsDoubleTime)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The original stays as it was
+	if parsedOsuFile.HitObjects.List[10].Time != originalTime || parsedOsuFile.Difficulty.ApproachRate != 8 {
+		t.Fatal("original map was changed")
+	}
+
+	if doubleTime.HitObjects.List[10].Time != originalTime/1.5 {
+		t.Fatalf("expected time %f, got %f", originalTime/1.5, doubleTime.HitObjects.List[10].Time)
+	}
+
+	//Preempt goes from 750ms to 500ms, the 300 hit window from 44ms to 29.33ms
+	if math.Abs(doubleTime.Difficulty.ApproachRate-9.6667) > 0.0001 || math.Abs(doubleTime.Difficulty.OverallDifficulty-8.4444) > 0.0001 {
+		t.Fatalf("wrong difficulty: %+v", doubleTime.Difficulty)
+	}
+
+	if math.Abs(doubleTime.FirstBpm-parsedOsuFile.FirstBpm*1.5) > 0.0001 || doubleTime.Length >= parsedOsuFile.Length {
+		t.Fatalf("wrong bpm or length: %f, %d", doubleTime.FirstBpm, doubleTime.Length)
+	}
+
+	normal, _ := parsedOsuFile.OsuDifficulty(osu_parser.ModsNone)
+	faster, _ := doubleTime.OsuDifficulty(osu_parser.ModsNone)
+
+	if !(faster.StarRating > normal.StarRating) || faster.MaxCombo != normal.MaxCombo {
+		t.Fatalf("double time isn't harder: %f against %f", faster.StarRating, normal.StarRating)
+	}
+
+	halfTime, _ := parsedOsuFile.ApplyMods(osu_parser.ModsHalfTime)
+
+	if halfTime.HitObjects.List[10].Time != originalTime/0.75 {
+		t.Fatalf("expected time %f, got %f", originalTime/0.75, halfTime.HitObjects.List[10].Time)
+	}
+}
@@ -0,0 +1,132 @@
+package osu_parser
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type OsbFile struct {
+	Md5Hash string
+
+	Storyboard Storyboard
+
+	Diagnostics []Diagnostic
+}
+
+func ParseStoryboardFile(filename string) (OsbFile, error) {
+	data, err := os.ReadFile(filename)
+
+	if err != nil {
+		return OsbFile{}, err
+	}
+
+	return ParseStoryboardBytes(data)
+}
+
+func ParseStoryboardBytes(bytes []byte) (OsbFile, error) {
+	return ParseStoryboardText(string(bytes))
+}
+
+func ParseStoryboardText(osbText string) (OsbFile, error) {
+	return ParseStoryboardWithOptions(strings.NewReader(osbText), ParseOptions{})
+}
+
+// Parses an .osb file with the same limits and cancellation as ParseWithOptions,
+// options which are about beatmaps like MaxHitObjects don't apply
+func ParseStoryboardWithOptions(reader io.Reader, options ParseOptions) (OsbFile, error) {
+	hash := md5.New()
+	lineReader := bufio.NewReader(io.TeeReader(reader, hash))
+
+	returnOsbFile := OsbFile{}
+	currentSection := SectionIgnore
+
+	diagnostics := &diagnosticCollector{
+		diagnostics: &returnOsbFile.Diagnostics,
+	}
+
+	storyboard := newStoryboardParser(&returnOsbFile.Storyboard, diagnostics)
+	storyboard.maxLineLength = options.MaxLineLength
+
+	var readErr error
+
+	for i := 0; readErr == nil; i++ {
+		if limitErr := options.checkStoryboardLimits(returnOsbFile.Diagnostics, storyboard); limitErr != nil {
+			return OsbFile{}, limitErr
+		}
+
+		rawLine := ""
+		rawLine, readErr = readCleanLine(lineReader, options.MaxLineLength)
+
+		if readErr != nil && readErr != io.EOF {
+			return OsbFile{}, readErr
+		}
+
+		line := strings.Trim(rawLine, "\t\r ")
+		lineOffset := len(rawLine) - len(strings.TrimLeft(rawLine, "\t\r "))
+
+		diagnostics.currentLine = rawLine
+
+		if len(line) == 0 || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		switch line {
+		case "[Variables]":
+			currentSection = SectionVariables
+			diagnostics.section = line
+			continue
+		case "[Events]":
+			currentSection = SectionEvents
+			diagnostics.section = line
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			currentSection = SectionIgnore
+			diagnostics.section = line
+			continue
+		}
+
+		switch currentSection {
+		case SectionVariables:
+			storyboard.parseVariable(i, line, lineOffset)
+		case SectionEvents:
+			//.osb files only hold storyboard objects, backgrounds and breaks belong to the difficulty
+			if !storyboard.parseLine(i, rawLine) {
+				diagnostics.error(i, "[Events]", line, lineOffset, fmt.Errorf("%w: event in storyboard file", ErrUnknownValue))
+			}
+		}
+	}
+
+	if limitErr := options.checkStoryboardLimits(returnOsbFile.Diagnostics, storyboard); limitErr != nil {
+		return OsbFile{}, limitErr
+	}
+
+	returnOsbFile.Md5Hash = hex.EncodeToString(hash.Sum(nil))
+
+	return returnOsbFile, nil
+}
+
+// Merges a difficulty specific storyboard into the beatmap set's shared one.
+// Objects are grouped by layer; inside of a layer the shared storyboard is drawn first,
+// with the difficulty specific objects drawn on top of it.
+func (storyboard Storyboard) Merge(difficulty Storyboard) Storyboard {
+	objects := make([]StoryboardObject, 0, len(storyboard.Objects)+len(difficulty.Objects))
+
+	objects = append(objects, storyboard.Objects...)
+	objects = append(objects, difficulty.Objects...)
+
+	sort.SliceStable(objects, func(a, b int) bool {
+		return objects[a].Layer < objects[b].Layer
+	})
+
+	return Storyboard{
+		Objects: objects,
+	}
+}
@@ -0,0 +1,842 @@
+package osu_parser
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	osuDifficultyMultiplier = 0.0675
+	osuPerformanceBase      = 1.14
+
+	//Distances are scaled to a circle of this radius, so every circle size can be treated the same
+	normalisedRadius    = 50.0
+	minDeltaTime        = 25.0
+	maximumSliderRadius = normalisedRadius * 2.4
+	assumedSliderRadius = normalisedRadius * 1.8
+
+	aimSkillMultiplier        = 23.55
+	aimStrainDecayBase        = 0.15
+	aimWideAngleMultiplier    = 1.5
+	aimAcuteAngleMultiplier   = 1.95
+	aimSliderMultiplier       = 1.35
+	aimVelocityChangeMultiple = 0.75
+
+	speedSkillMultiplier     = 1375.0
+	speedStrainDecayBase     = 0.3
+	speedSingleSpacing       = 125.0
+	speedMinBonusTime        = 75.0
+	speedBalancingFactor     = 40.0
+	rhythmHistoryTimeMax     = 5000.0
+	rhythmHistoryObjectsMax  = 32
+	rhythmMultiplier         = 0.75
+	flashlightSkillMultipler = 0.052
+	flashlightStrainDecay    = 0.15
+	flashlightMaxOpacity     = 0.4
+	flashlightMinVelocity    = 0.5
+	flashlightSliderMultiple = 1.3
+	flashlightMinAngleMult   = 0.2
+	flashlightHiddenBonus    = 0.2
+
+	//With Hidden objects fade in over this much of their preempt time, then fade out over the next part
+	hiddenFadeInMultiplier  = 0.4
+	hiddenFadeOutMultiplier = 0.3
+
+	//Sections with the highest strain get reduced to account for short difficulty spikes
+	osuReducedSectionCount   = 10
+	osuReducedStrainBaseline = 0.75
+	osuDecayWeight           = 0.9
+)
+
+type OsuDifficultyAttributes struct {
+	StarRating float64
+
+	AimDifficulty        float64
+	SpeedDifficulty      float64
+	FlashlightDifficulty float64
+	//How many objects are relevant to the speed difficulty
+	SpeedNoteCount float64
+	//Aim difficulty without sliders divided by aim difficulty with them
+	SliderFactor float64
+
+	ApproachRate      float64
+	OverallDifficulty float64
+	DrainRate         float64
+
+	MaxCombo       int
+	HitCircleCount int
+	SliderCount    int
+	SpinnerCount   int
+}
+
+// A hit object with everything difficulty calculation needs, stacking included
+type osuDifficultyBase struct {
+	hitObject *HitObject
+
+	startTime          float64
+	radius             float64
+	preempt            float64
+	fadeIn             float64
+	stackedPosition    Vec2
+	stackedEndPosition Vec2
+
+	//Slider specific, where a lazy player would move their cursor
+	lazyEndPosition    Vec2
+	lazyTravelDistance float64
+	lazyTravelTime     float64
+}
+
+// Movement from the previous object to this one
+type osuDifficultyObject struct {
+	index   int
+	objects []*osuDifficultyObject
+
+	base *osuDifficultyBase
+	last *osuDifficultyBase
+
+	startTime      float64
+	deltaTime      float64
+	strainTime     float64
+	hitWindowGreat float64
+
+	lazyJumpDistance    float64
+	minimumJumpDistance float64
+	minimumJumpTime     float64
+	travelDistance      float64
+	travelTime          float64
+
+	angle    float64
+	hasAngle bool
+}
+
+func (object *osuDifficultyObject) previous(backwards int) *osuDifficultyObject {
+	index := object.index - (backwards + 1)
+
+	if index < 0 {
+		return nil
+	}
+
+	return object.objects[index]
+}
+
+func (object *osuDifficultyObject) next(forwards int) *osuDifficultyObject {
+	index := object.index + forwards + 1
+
+	if index >= len(object.objects) {
+		return nil
+	}
+
+	return object.objects[index]
+}
+
+func (object *osuDifficultyObject) isSlider() bool {
+	return object.base.hitObject.Type == HitObjectTypeSlider
+}
+
+func (object *osuDifficultyObject) isSpinner() bool {
+	return object.base.hitObject.Type == HitObjectTypeSpinner
+}
+
+// How visible the object is at time, 0 once it should've been hit
+func (object *osuDifficultyObject) opacityAt(time float64, hidden bool) float64 {
+	if time > object.base.startTime {
+		return 0
+	}
+
+	fadeInStart := object.base.startTime - object.base.preempt
+	opacity := math.Max(0, math.Min(1, (time-fadeInStart)/object.base.fadeIn))
+
+	if hidden {
+		fadeOutStart := fadeInStart + object.base.fadeIn
+		fadeOut := math.Max(0, math.Min(1, (time-fadeOutStart)/(object.base.preempt*hiddenFadeOutMultiplier)))
+
+		opacity = math.Min(opacity, 1-fadeOut)
+	}
+
+	return opacity
+}
+
+// Star rating and difficulty attributes for an osu!standard map played with mods, like Performance takes them.
+// This is osu!lazer's difficulty calculation from the 2022 performance points update,
+// star ratings of the later reworks which the website shows now come out different.
+// Mods changing the map are applied with ApplyMods, so a map which already had them applied
+// should be given ModsNone. Flashlight only counts towards the star rating with the mod enabled
+func (osuFile *OsuFile) OsuDifficulty(mods Mods) (OsuDifficultyAttributes, error) {
+	attributes := OsuDifficultyAttributes{}
+
+	if osuFile.General.Mode != PlaymodeOsu {
+		return attributes, ErrUnsupportedConversion
+	}
+
+	modded, err := osuFile.ApplyMods(mods)
+
+	if err != nil {
+		return attributes, err
+	}
+
+	osuFile = &modded
+
+	if err := checkHitObjectTimes(osuFile.HitObjects.List); err != nil {
+		return attributes, err
+	}
+
+	preempt := osuFile.Difficulty.Preempt()
+	hitWindowGreat := osuFile.Difficulty.HitWindows(PlaymodeOsu).Great
+
+	attributes.ApproachRate = approachRateFromPreempt(preempt)
+	attributes.OverallDifficulty = (80 - hitWindowGreat) / 6
+	attributes.DrainRate = osuFile.Difficulty.HPDrainRate
+	attributes.MaxCombo = osuFile.MaxCombo()
+
+	for i := range osuFile.HitObjects.List {
+		switch osuFile.HitObjects.List[i].Type {
+		case HitObjectTypeSlider:
+			attributes.SliderCount++
+		case HitObjectTypeSpinner:
+			attributes.SpinnerCount++
+		default:
+			attributes.HitCircleCount++
+		}
+	}
+
+	if len(osuFile.HitObjects.List) == 0 {
+		return attributes, nil
+	}
+
+	hidden := mods.Has(ModsHidden)
+	objects := osuFile.osuDifficultyObjects(preempt, hitWindowGreat*2, hidden)
+
+	aimRating := math.Sqrt(osuAimDifficulty(objects, true)) * osuDifficultyMultiplier
+	aimRatingNoSliders := math.Sqrt(osuAimDifficulty(objects, false)) * osuDifficultyMultiplier
+	speedDifficulty, speedNoteCount := osuSpeedDifficulty(objects)
+	speedRating := math.Sqrt(speedDifficulty) * osuDifficultyMultiplier
+	flashlightRating := math.Sqrt(osuFlashlightDifficulty(objects, hidden)) * osuDifficultyMultiplier
+
+	//Aiming with touch is a lot easier
+	if mods.Has(ModsTouchDevice) {
+		aimRating = math.Pow(aimRating, 0.8)
+		flashlightRating = math.Pow(flashlightRating, 0.8)
+	}
+
+	//Relax takes tapping away
+	if mods.Has(ModsRelax) {
+		aimRating *= 0.9
+		speedRating = 0
+		flashlightRating *= 0.7
+	}
+
+	attributes.AimDifficulty = aimRating
+	attributes.SpeedDifficulty = speedRating
+	attributes.SpeedNoteCount = speedNoteCount
+	attributes.FlashlightDifficulty = flashlightRating
+	attributes.SliderFactor = 1
+
+	if aimRating > 0 {
+		attributes.SliderFactor = aimRatingNoSliders / aimRating
+	}
+
+	baseAimPerformance := math.Pow(5*math.Max(1, aimRating/0.0675)-4, 3) / 100000
+	baseSpeedPerformance := math.Pow(5*math.Max(1, speedRating/0.0675)-4, 3) / 100000
+
+	baseFlashlightPerformance := 0.0
+
+	if mods.Has(ModsFlashlight) {
+		baseFlashlightPerformance = math.Pow(flashlightRating, 2) * 25
+	}
+
+	basePerformance := norm(1.1, baseAimPerformance, baseSpeedPerformance, baseFlashlightPerformance)
+
+	if basePerformance > 0.00001 {
+		attributes.StarRating = math.Cbrt(osuPerformanceBase) * 0.027 * (math.Cbrt(100000/math.Pow(2, 1/1.1)*basePerformance) + 4)
+	}
+
+	return attributes, nil
+}
+
+func (osuFile *OsuFile) osuDifficultyObjects(preempt float64, hitWindowGreat float64, hidden bool) []*osuDifficultyObject {
+	radius := osuFile.Difficulty.CircleRadius()
+	fadeIn := 400 * math.Min(1, preempt/450)
+
+	if hidden {
+		fadeIn = preempt * hiddenFadeInMultiplier
+	}
+	bases := make([]*osuDifficultyBase, len(osuFile.HitObjects.List))
+
+	for i := range osuFile.HitObjects.List {
+		hitObject := &osuFile.HitObjects.List[i]
+		stackOffset := hitObject.StackedPosition.sub(hitObject.Position)
+
+		base := &osuDifficultyBase{
+			hitObject:          hitObject,
+			startTime:          hitObject.Time,
+			radius:             radius,
+			preempt:            preempt,
+			fadeIn:             fadeIn,
+			stackedPosition:    hitObject.StackedPosition,
+			stackedEndPosition: hitObject.StackedPosition,
+		}
+
+		if hitObject.Type == HitObjectTypeSlider {
+			base.computeSliderCursor(osuFile.SliderTiming(hitObject), stackOffset)
+		}
+
+		bases[i] = base
+	}
+
+	objects := []*osuDifficultyObject{}
+
+	for i := 1; i < len(bases); i++ {
+		var lastLast *osuDifficultyBase
+
+		if i > 1 {
+			lastLast = bases[i-2]
+		}
+
+		object := &osuDifficultyObject{
+			index:          len(objects),
+			base:           bases[i],
+			last:           bases[i-1],
+			startTime:      bases[i].startTime,
+			deltaTime:      bases[i].startTime - bases[i-1].startTime,
+			hitWindowGreat: hitWindowGreat,
+		}
+
+		//Spinners can't be hit, so they have no hit window
+		if object.isSpinner() {
+			object.hitWindowGreat = 0
+		}
+
+		object.strainTime = math.Max(object.deltaTime, minDeltaTime)
+		object.setDistances(lastLast)
+
+		objects = append(objects, object)
+	}
+
+	for _, object := range objects {
+		object.objects = objects
+	}
+
+	return objects
+}
+
+// Follows the slider the way a lazy player would, only moving the cursor when the ball would leave it
+func (base *osuDifficultyBase) computeSliderCursor(timing SliderTiming, stackOffset Vec2) {
+	base.stackedEndPosition = timing.EndPosition().add(stackOffset)
+
+	nested := []SliderEvent{}
+
+	//The legacy last tick replaces the tail, the player only needs to hold the slider until then
+	for _, event := range timing.NestedObjects() {
+		switch event.Type {
+		case SliderEventTail:
+			continue
+		case SliderEventLegacyLastTick:
+			event.Position = timing.EndPosition()
+		}
+
+		event.Position = event.Position.add(stackOffset)
+		nested = append(nested, event)
+	}
+
+	//The legacy last tick can come before the last span's ticks
+	sort.SliceStable(nested, func(i, j int) bool {
+		return nested[i].Time < nested[j].Time
+	})
+
+	base.lazyTravelTime = nested[len(nested)-1].Time - timing.StartTime
+
+	endTimeMin := 0.0
+
+	if timing.SpanDuration != 0 {
+		endTimeMin = base.lazyTravelTime / timing.SpanDuration
+	}
+
+	if math.Mod(endTimeMin, 2) >= 1 {
+		endTimeMin = 1 - math.Mod(endTimeMin, 1)
+	} else {
+		endTimeMin = math.Mod(endTimeMin, 1)
+	}
+
+	//Temporary until the actual lazy end position is known
+	base.lazyEndPosition = timing.Path.PositionAt(endTimeMin).add(stackOffset)
+
+	cursorPosition := base.stackedPosition
+	scalingFactor := normalisedRadius / base.radius
+
+	for i := 1; i < len(nested); i++ {
+		movement := nested[i].Position.sub(cursorPosition)
+		movementLength := scalingFactor * movement.length()
+
+		//Amount of movement needed for the cursor position to be updated
+		requiredMovement := assumedSliderRadius
+
+		if i == len(nested)-1 {
+			//At the end the player can take whichever of the lazy end position and the real one is closer
+			lazyMovement := base.lazyEndPosition.sub(cursorPosition)
+
+			if lazyMovement.length() < movement.length() {
+				movement = lazyMovement
+			}
+
+			movementLength = scalingFactor * movement.length()
+		} else if nested[i].Type == SliderEventRepeat {
+			//Repeats need tighter movement
+			requiredMovement = normalisedRadius
+		}
+
+		if movementLength > requiredMovement {
+			cursorPosition = cursorPosition.add(movement.scale((movementLength - requiredMovement) / movementLength))
+			movementLength *= (movementLength - requiredMovement) / movementLength
+			base.lazyTravelDistance += movementLength
+		}
+
+		if i == len(nested)-1 {
+			base.lazyEndPosition = cursorPosition
+		}
+	}
+}
+
+func (base *osuDifficultyBase) endCursorPosition() Vec2 {
+	if base.hitObject.Type == HitObjectTypeSlider {
+		return base.lazyEndPosition
+	}
+
+	return base.stackedPosition
+}
+
+func (object *osuDifficultyObject) setDistances(lastLast *osuDifficultyBase) {
+	if object.isSlider() {
+		object.travelDistance = object.base.lazyTravelDistance
+		object.travelTime = math.Max(object.base.lazyTravelTime, minDeltaTime)
+	}
+
+	//Nothing to aim for when spinners are involved
+	if object.isSpinner() || object.last.hitObject.Type == HitObjectTypeSpinner {
+		return
+	}
+
+	scalingFactor := normalisedRadius / object.base.radius
+
+	//Small circles get a bonus
+	if object.base.radius < 30 {
+		scalingFactor *= 1 + math.Min(30-object.base.radius, 5)/50
+	}
+
+	lastCursorPosition := object.last.endCursorPosition()
+
+	object.lazyJumpDistance = object.base.stackedPosition.scale(scalingFactor).sub(lastCursorPosition.scale(scalingFactor)).length()
+	object.minimumJumpTime = object.strainTime
+	object.minimumJumpDistance = object.lazyJumpDistance
+
+	if object.last.hitObject.Type == HitObjectTypeSlider {
+		lastTravelTime := math.Max(object.last.lazyTravelTime, minDeltaTime)
+		object.minimumJumpTime = math.Max(object.strainTime-lastTravelTime, minDeltaTime)
+
+		//The cursor could've left the last slider from anywhere within the follow circle
+		tailJumpDistance := object.last.stackedEndPosition.sub(object.base.stackedPosition).length() * scalingFactor
+
+		object.minimumJumpDistance = math.Max(0, math.Min(object.lazyJumpDistance-(maximumSliderRadius-assumedSliderRadius), tailJumpDistance-maximumSliderRadius))
+	}
+
+	if lastLast != nil && lastLast.hitObject.Type != HitObjectTypeSpinner {
+		lastLastCursorPosition := lastLast.endCursorPosition()
+
+		v1 := lastLastCursorPosition.sub(object.last.stackedPosition)
+		v2 := object.base.stackedPosition.sub(lastCursorPosition)
+
+		dot := v1.dot(v2)
+		det := v1.X*v2.Y - v1.Y*v2.X
+
+		object.angle = math.Abs(math.Atan2(det, dot))
+		object.hasAngle = true
+	}
+}
+
+func wideAngleBonus(angle float64) float64 {
+	return math.Pow(math.Sin(3.0/4*(math.Min(5.0/6*math.Pi, math.Max(math.Pi/6, angle))-math.Pi/6)), 2)
+}
+
+func acuteAngleBonus(angle float64) float64 {
+	return 1 - wideAngleBonus(angle)
+}
+
+func evaluateAim(current *osuDifficultyObject, withSliders bool) float64 {
+	if current.isSpinner() || current.index <= 1 || current.previous(0).isSpinner() {
+		return 0
+	}
+
+	last := current.previous(0)
+	lastLast := current.previous(1)
+
+	//Velocity to the current object, extended through the last object if it's a slider
+	currentVelocity := current.lazyJumpDistance / current.strainTime
+
+	if last.isSlider() && withSliders {
+		travelVelocity := last.travelDistance / last.travelTime
+		movementVelocity := current.minimumJumpDistance / current.minimumJumpTime
+
+		currentVelocity = math.Max(currentVelocity, movementVelocity+travelVelocity)
+	}
+
+	previousVelocity := last.lazyJumpDistance / last.strainTime
+
+	if lastLast.isSlider() && withSliders {
+		travelVelocity := lastLast.travelDistance / lastLast.travelTime
+		movementVelocity := last.minimumJumpDistance / last.minimumJumpTime
+
+		previousVelocity = math.Max(previousVelocity, movementVelocity+travelVelocity)
+	}
+
+	wideBonus := 0.0
+	acuteBonus := 0.0
+	sliderBonus := 0.0
+	velocityChangeBonus := 0.0
+
+	aimStrain := currentVelocity
+
+	//Only rewards angles when the rhythm stays the same
+	if math.Max(current.strainTime, last.strainTime) < 1.25*math.Min(current.strainTime, last.strainTime) {
+		if current.hasAngle && last.hasAngle && lastLast.hasAngle {
+			angleBonus := math.Min(currentVelocity, previousVelocity)
+
+			wideBonus = wideAngleBonus(current.angle)
+			acuteBonus = acuteAngleBonus(current.angle)
+
+			//Only buff faster than 300 bpm 1/2
+			if current.strainTime > 100 {
+				acuteBonus = 0
+			} else {
+				acuteBonus *= acuteAngleBonus(last.angle) *
+					math.Min(angleBonus, 125/current.strainTime) *
+					math.Pow(math.Sin(math.Pi/2*math.Min(1, (100-current.strainTime)/25)), 2) *
+					math.Pow(math.Sin(math.Pi/2*(math.Max(50, math.Min(100, current.lazyJumpDistance))-50)/50), 2)
+			}
+
+			//Repeated angles get penalised less the more they differ from the one before
+			wideBonus *= angleBonus * (1 - math.Min(wideBonus, math.Pow(wideAngleBonus(last.angle), 3)))
+			acuteBonus *= 0.5 + 0.5*(1-math.Min(acuteBonus, math.Pow(acuteAngleBonus(lastLast.angle), 3)))
+		}
+	}
+
+	if math.Max(previousVelocity, currentVelocity) != 0 {
+		//Average velocity over the whole object, not the jump and slider separately
+		previousVelocity = (last.lazyJumpDistance + lastLast.travelDistance) / last.strainTime
+		currentVelocity = (current.lazyJumpDistance + last.travelDistance) / current.strainTime
+
+		distanceRatio := math.Pow(math.Sin(math.Pi/2*math.Abs(previousVelocity-currentVelocity)/math.Max(previousVelocity, currentVelocity)), 2)
+		overlapVelocityBuff := math.Min(125/math.Min(current.strainTime, last.strainTime), math.Abs(previousVelocity-currentVelocity))
+
+		velocityChangeBonus = overlapVelocityBuff * distanceRatio
+		velocityChangeBonus *= math.Pow(math.Min(current.strainTime, last.strainTime)/math.Max(current.strainTime, last.strainTime), 2)
+	}
+
+	if last.isSlider() {
+		sliderBonus = last.travelDistance / last.travelTime
+	}
+
+	aimStrain += math.Max(acuteBonus*aimAcuteAngleMultiplier, wideBonus*aimWideAngleMultiplier+velocityChangeBonus*aimVelocityChangeMultiple)
+
+	if withSliders {
+		aimStrain += sliderBonus * aimSliderMultiplier
+	}
+
+	return aimStrain
+}
+
+func evaluateSpeed(current *osuDifficultyObject) float64 {
+	if current.isSpinner() {
+		return 0
+	}
+
+	strainTime := current.strainTime
+	doubletapness := 1.0
+
+	//Doubles which can be doubletapped get nerfed
+	if next := current.next(0); next != nil {
+		currentDeltaTime := math.Max(1, current.deltaTime)
+		nextDeltaTime := math.Max(1, next.deltaTime)
+		deltaDifference := math.Abs(nextDeltaTime - currentDeltaTime)
+		speedRatio := currentDeltaTime / math.Max(currentDeltaTime, deltaDifference)
+		windowRatio := math.Pow(math.Min(1, currentDeltaTime/current.hitWindowGreat), 2)
+
+		doubletapness = math.Pow(speedRatio, 1-windowRatio)
+	}
+
+	//Caps the delta time to the 300 hit window
+	strainTime /= math.Max(0.92, math.Min(1, (strainTime/current.hitWindowGreat)/0.93))
+
+	speedBonus := 1.0
+
+	if strainTime < speedMinBonusTime {
+		speedBonus = 1 + 0.75*math.Pow((speedMinBonusTime-strainTime)/speedBalancingFactor, 2)
+	}
+
+	travelDistance := 0.0
+
+	if previous := current.previous(0); previous != nil {
+		travelDistance = previous.travelDistance
+	}
+
+	distance := math.Min(speedSingleSpacing, travelDistance+current.minimumJumpDistance)
+
+	return (speedBonus + speedBonus*math.Pow(distance/speedSingleSpacing, 3.5)) * doubletapness / strainTime
+}
+
+// Multiplier for how complex the rhythm leading up to the object is
+func evaluateRhythm(current *osuDifficultyObject) float64 {
+	if current.isSpinner() {
+		return 0
+	}
+
+	previousIslandSize := 0
+	rhythmComplexitySum := 0.0
+	islandSize := 1
+	startRatio := 0.0
+	firstDeltaSwitch := false
+
+	historicalNoteCount := min(current.index, rhythmHistoryObjectsMax)
+	rhythmStart := 0
+
+	for rhythmStart < historicalNoteCount-2 && current.startTime-current.previous(rhythmStart).startTime < rhythmHistoryTimeMax {
+		rhythmStart++
+	}
+
+	for i := rhythmStart; i > 0; i-- {
+		currentObject := current.previous(i - 1)
+		previousObject := current.previous(i)
+		lastObject := current.previous(i + 1)
+
+		//Scales from 0 for the oldest note to 1 for the current one, by time or by object count
+		historicalDecay := (rhythmHistoryTimeMax - (current.startTime - currentObject.startTime)) / rhythmHistoryTimeMax
+		historicalDecay = math.Min(float64(historicalNoteCount-i)/float64(historicalNoteCount), historicalDecay)
+
+		currentDelta := currentObject.strainTime
+		previousDelta := previousObject.strainTime
+		lastDelta := lastObject.strainTime
+
+		currentRatio := 1 + 6*math.Min(0.5, math.Pow(math.Sin(math.Pi/(math.Min(previousDelta, currentDelta)/math.Max(previousDelta, currentDelta))), 2))
+
+		windowPenalty := math.Min(1, math.Max(0, math.Abs(previousDelta-currentDelta)-currentObject.hitWindowGreat*0.3)/(currentObject.hitWindowGreat*0.3))
+		effectiveRatio := windowPenalty * currentRatio
+
+		if firstDeltaSwitch {
+			if !(previousDelta > 1.25*currentDelta || previousDelta*1.25 < currentDelta) {
+				//The island is still going
+				if islandSize < 7 {
+					islandSize++
+				}
+			} else {
+				//Rhythm changes into or out of sliders are easier
+				if currentObject.isSlider() {
+					effectiveRatio *= 0.125
+				}
+
+				if previousObject.isSlider() {
+					effectiveRatio *= 0.25
+				}
+
+				//Repeated island sizes and polarity
+				if previousIslandSize == islandSize {
+					effectiveRatio *= 0.25
+				}
+
+				if previousIslandSize%2 == islandSize%2 {
+					effectiveRatio *= 0.5
+				}
+
+				//The previous increase happened a note ago, like 1/1 to 1/2 to 1/4
+				if lastDelta > previousDelta+10 && previousDelta > currentDelta+10 {
+					effectiveRatio *= 0.125
+				}
+
+				rhythmComplexitySum += math.Sqrt(effectiveRatio*startRatio) * historicalDecay * math.Sqrt(4+float64(islandSize)) / 2 * math.Sqrt(4+float64(previousIslandSize)) / 2
+
+				startRatio = effectiveRatio
+				previousIslandSize = islandSize
+
+				//Slowing down stops counting
+				if previousDelta*1.25 < currentDelta {
+					firstDeltaSwitch = false
+				}
+
+				islandSize = 1
+			}
+		} else if previousDelta > 1.25*currentDelta {
+			//Speeding up starts counting an island until the speed changes again
+			firstDeltaSwitch = true
+			startRatio = effectiveRatio
+			islandSize = 1
+		}
+	}
+
+	return math.Sqrt(4+rhythmComplexitySum*rhythmMultiplier) / 2
+}
+
+func evaluateFlashlight(current *osuDifficultyObject, hidden bool) float64 {
+	if current.isSpinner() {
+		return 0
+	}
+
+	scalingFactor := 52.0 / current.base.radius
+	smallDistanceNerf := 1.0
+	cumulativeStrainTime := 0.0
+	result := 0.0
+	angleRepeatCount := 0.0
+
+	last := current
+
+	//Goes backwards in time from the current object
+	for i := 0; i < min(current.index, 10); i++ {
+		object := current.previous(i)
+
+		if !object.isSpinner() {
+			cumulativeStrainTime += last.strainTime
+			jumpDistance := current.base.stackedPosition.sub(object.base.stackedEndPosition).length()
+
+			//Objects within the flashlight radius are easy to see
+			if i == 0 {
+				smallDistanceNerf = math.Min(1, jumpDistance/75)
+			}
+
+			//Only the first object of a stack counts
+			stackNerf := math.Min(1, (object.lazyJumpDistance/scalingFactor)/25)
+			opacityBonus := 1 + flashlightMaxOpacity*(1-current.opacityAt(object.base.startTime, hidden))
+
+			result += stackNerf * opacityBonus * scalingFactor * jumpDistance / cumulativeStrainTime
+
+			if object.hasAngle && current.hasAngle && math.Abs(object.angle-current.angle) < 0.02 {
+				angleRepeatCount += math.Max(1-0.1*float64(i), 0)
+			}
+		}
+
+		last = object
+	}
+
+	result = math.Pow(smallDistanceNerf*result, 2)
+
+	//Objects fading out make it even harder to see where to go
+	if hidden {
+		result *= 1 + flashlightHiddenBonus
+	}
+	result *= flashlightMinAngleMult + (1-flashlightMinAngleMult)/(angleRepeatCount+1)
+
+	sliderBonus := 0.0
+
+	if current.isSlider() {
+		//Travel distance without the circle size scaling
+		pixelTravelDistance := current.base.lazyTravelDistance / scalingFactor
+
+		sliderBonus = math.Pow(math.Max(0, pixelTravelDistance/current.travelTime-flashlightMinVelocity), 0.5)
+		sliderBonus *= pixelTravelDistance
+
+		//Repeats need less memorisation
+		if repeats := current.base.hitObject.RepeatCount - 1; repeats > 0 {
+			sliderBonus /= float64(repeats + 1)
+		}
+	}
+
+	return result + sliderBonus*flashlightSliderMultiple
+}
+
+// Highest strains count less, then everything gets summed up by weight
+func osuStrainDifficulty(peaks []float64, reducedSectionCount int, difficultyMultiplier float64) float64 {
+	strains := []float64{}
+
+	//Empty sections don't count
+	for _, peak := range peaks {
+		if peak > 0 {
+			strains = append(strains, peak)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.Float64Slice(strains)))
+
+	for i := 0; i < min(len(strains), reducedSectionCount); i++ {
+		scale := math.Log10(1 + 9*math.Max(0, math.Min(1, float64(i)/float64(reducedSectionCount))))
+
+		strains[i] *= osuReducedStrainBaseline + (1-osuReducedStrainBaseline)*scale
+	}
+
+	return weightedStrainSum(strains, osuDecayWeight) * difficultyMultiplier
+}
+
+func osuAimDifficulty(objects []*osuDifficultyObject, withSliders bool) float64 {
+	peaks := strainPeaks{}
+	currentStrain := 0.0
+
+	for _, object := range objects {
+		peaks.process(object.index, object.startTime, func() float64 {
+			currentStrain *= strainDecay(aimStrainDecayBase, object.deltaTime)
+			currentStrain += evaluateAim(object, withSliders) * aimSkillMultiplier
+
+			return currentStrain
+		}, func(time float64) float64 {
+			return currentStrain * strainDecay(aimStrainDecayBase, time-object.previous(0).startTime)
+		})
+	}
+
+	return osuStrainDifficulty(peaks.all(), osuReducedSectionCount, 1.06)
+}
+
+// Speed difficulty and how many notes are relevant to it
+func osuSpeedDifficulty(objects []*osuDifficultyObject) (float64, float64) {
+	peaks := strainPeaks{}
+	currentStrain := 0.0
+	currentRhythm := 0.0
+	objectStrains := []float64{}
+
+	for _, object := range objects {
+		peaks.process(object.index, object.startTime, func() float64 {
+			currentStrain *= strainDecay(speedStrainDecayBase, object.strainTime)
+			currentStrain += evaluateSpeed(object) * speedSkillMultiplier
+			currentRhythm = evaluateRhythm(object)
+
+			totalStrain := currentStrain * currentRhythm
+			objectStrains = append(objectStrains, totalStrain)
+
+			return totalStrain
+		}, func(time float64) float64 {
+			return currentStrain * currentRhythm * strainDecay(speedStrainDecayBase, time-object.previous(0).startTime)
+		})
+	}
+
+	maxStrain := 0.0
+
+	for _, strain := range objectStrains {
+		maxStrain = math.Max(maxStrain, strain)
+	}
+
+	relevantNotes := 0.0
+
+	if maxStrain != 0 {
+		for _, strain := range objectStrains {
+			relevantNotes += 1 / (1 + math.Exp(-(strain/maxStrain*12 - 6)))
+		}
+	}
+
+	return osuStrainDifficulty(peaks.all(), 5, 1.04), relevantNotes
+}
+
+func osuFlashlightDifficulty(objects []*osuDifficultyObject, hidden bool) float64 {
+	peaks := strainPeaks{}
+	currentStrain := 0.0
+
+	for _, object := range objects {
+		peaks.process(object.index, object.startTime, func() float64 {
+			currentStrain *= strainDecay(flashlightStrainDecay, object.deltaTime)
+			currentStrain += evaluateFlashlight(object, hidden) * flashlightSkillMultipler
+
+			return currentStrain
+		}, func(time float64) float64 {
+			return currentStrain * strainDecay(flashlightStrainDecay, time-object.previous(0).startTime)
+		})
+	}
+
+	sum := 0.0
+
+	for _, peak := range peaks.all() {
+		sum += peak
+	}
+
+	return sum * 1.06
+}
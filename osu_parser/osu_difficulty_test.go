@@ -0,0 +1,164 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+// Values of the 2022 algorithm OsuDifficulty implements
+func TestOsuDifficulty(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	attributes, err := parsedOsuFile.OsuDifficulty(osu_parser.ModsNone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][2]float64{
+		"star rating": {attributes.StarRating, 3.8974},
+		"aim":         {attributes.AimDifficulty, 1.9996},
+		"speed":       {attributes.SpeedDifficulty, 1.7034},
+		"flashlight":  {attributes.FlashlightDifficulty, 0.5357},
+		"speed notes": {attributes.SpeedNoteCount, 30.9811},
+		"slider":      {attributes.SliderFactor, 0.9568},
+		"AR":          {attributes.ApproachRate, 8},
+		"OD":          {attributes.OverallDifficulty, 6},
+	}
+
+	for name, values := range expected {
+		if math.Abs(values[0]-values[1]) > 0.0001 {
+			t.Fatalf("expected %s %f, got %f", name, values[1], values[0])
+		}
+	}
+
+	if attributes.MaxCombo != 90 || attributes.HitCircleCount != 34 || attributes.SliderCount != 27 || attributes.SpinnerCount != 2 {
+		t.Fatalf("wrong counts: %+v", attributes)
+	}
+}
+
+func TestOsuDifficultyMods(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	difficulty := func(mods osu_parser.Mods) osu_parser.OsuDifficultyAttributes {
+		attributes, err := parsedOsuFile.OsuDifficulty(mods)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return attributes
+	}
+
+	noMod := difficulty(osu_parser.ModsNone)
+	flashlight := difficulty(osu_parser.ModsFlashlight)
+	hiddenFlashlight := difficulty(osu_parser.ModsHidden | osu_parser.ModsFlashlight)
+
+	//Flashlight adds to the star rating but doesn't change the skills themselves
+	if !(flashlight.StarRating > noMod.StarRating) || flashlight.FlashlightDifficulty != noMod.FlashlightDifficulty {
+		t.Fatalf("flashlight not counted: %f against %f", flashlight.StarRating, noMod.StarRating)
+	}
+
+	if !(hiddenFlashlight.FlashlightDifficulty > flashlight.FlashlightDifficulty) || hiddenFlashlight.AimDifficulty != noMod.AimDifficulty {
+		t.Fatalf("hidden doesn't make flashlight harder: %f against %f", hiddenFlashlight.FlashlightDifficulty, flashlight.FlashlightDifficulty)
+	}
+
+	//Mods given here are the same as applying them first
+	doubleTime, _ := parsedOsuFile.ApplyMods(osu_parser.ModsDoubleTime)
+	applied, _ := doubleTime.OsuDifficulty(osu_parser.ModsNone)
+
+	if difficulty(osu_parser.ModsDoubleTime) != applied {
+		t.Fatal("double time differs from applying it first")
+	}
+
+	if touchDevice := difficulty(osu_parser.ModsTouchDevice); math.Abs(touchDevice.AimDifficulty-math.Pow(noMod.AimDifficulty, 0.8)) > 0.0001 || touchDevice.SpeedDifficulty != noMod.SpeedDifficulty {
+		t.Fatalf("wrong touch device aim: %+v", touchDevice)
+	}
+
+	if relax := difficulty(osu_parser.ModsRelax); relax.SpeedDifficulty != 0 || math.Abs(relax.AimDifficulty-noMod.AimDifficulty*0.9) > 0.0001 {
+		t.Fatalf("wrong relax skills: %+v", relax)
+	}
+
+	if _, err := parsedOsuFile.OsuDifficulty(osu_parser.ModsEasy | osu_parser.ModsHardRock); !errors.Is(err, osu_parser.ErrIncompatibleMods) {
+		t.Fatalf("expected ErrIncompatibleMods, got %v", err)
+	}
+}
+
+func TestOsuDifficultyStreams(t *testing.T) {
+	stream := func(spacing int) string {
+		text := "osu file format v14\n\n[Difficulty]\nCircleSize:4\nOverallDifficulty:8\nApproachRate:9\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n"
+
+		for i := 0; i < 64; i++ {
+			text += fmt.Sprintf("%d,192,%d,1,0\n", 100+(i%2)*100, i*spacing)
+		}
+
+		return text
+	}
+
+	slow, _ := osu_parser.ParseText(stream(250))
+	fast, _ := osu_parser.ParseText(stream(125))
+
+	slowAttributes, _ := slow.OsuDifficulty(osu_parser.ModsNone)
+	fastAttributes, _ := fast.OsuDifficulty(osu_parser.ModsNone)
+
+	if !(fastAttributes.StarRating > slowAttributes.StarRating) || !(fastAttributes.SpeedDifficulty > slowAttributes.SpeedDifficulty) {
+		t.Fatalf("faster stream isn't harder: %f against %f", fastAttributes.StarRating, slowAttributes.StarRating)
+	}
+
+	//No sliders, no difference in aim
+	if fastAttributes.SliderFactor != 1 || fastAttributes.ApproachRate != 9 || fastAttributes.OverallDifficulty != 8 {
+		t.Fatalf("wrong attributes: %+v", fastAttributes)
+	}
+}
+
+func TestOsuDifficultyEmpty(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[Difficulty]\nApproachRate:4\n")
+
+	attributes, err := parsedOsuFile.OsuDifficulty(osu_parser.ModsNone)
+
+	if err != nil || attributes.StarRating != 0 || math.Abs(attributes.ApproachRate-4) > 0.0001 {
+		t.Fatalf("expected 0 stars at AR4, got %+v (%v)", attributes, err)
+	}
+
+	taiko, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nMode: 1\n")
+
+	if _, err := taiko.OsuDifficulty(osu_parser.ModsNone); !errors.Is(err, osu_parser.ErrUnsupportedConversion) {
+		t.Fatalf("expected ErrUnsupportedConversion, got %v", err)
+	}
+}
+
+// Breaks are skipped over, so two objects far apart don't take long
+func TestDifficultyLongBreak(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[HitObjects]\n100,100,0,1,0\n200,200,500,1,0\n300,300,2000000000,1,0\n")
+
+	if len(parsedOsuFile.HitObjects.List) != 3 {
+		t.Fatalf("expected 3 hit objects, got %d", len(parsedOsuFile.HitObjects.List))
+	}
+
+	if _, err := parsedOsuFile.OsuDifficulty(osu_parser.ModsNone); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parsedOsuFile.ManiaDifficulty(osu_parser.ModsNone); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parsedOsuFile.CatchDifficulty(osu_parser.ModsNone); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parsedOsuFile.TaikoDifficulty(osu_parser.ModsNone); err != nil {
+		t.Fatal(err)
+	}
+
+	//Stable doesn't accept times outside of the int32 range
+	parsedOsuFile.HitObjects.List[2].Time = 3000000000
+
+	if _, err := parsedOsuFile.OsuDifficulty(osu_parser.ModsNone); !errors.Is(err, osu_parser.ErrInvalidNumber) {
+		t.Fatalf("expected ErrInvalidNumber, got %v", err)
+	}
+}
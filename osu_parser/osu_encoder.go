@@ -0,0 +1,518 @@
+package osu_parser
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The format version written by the encoder
+const EncoderVersion = 14
+
+type keyValue struct {
+	key   string
+	value string
+}
+
+var sampleSetNames = map[SampleSet]string{
+	SampleSetNormal: "Normal",
+	SampleSetSoft:   "Soft",
+	SampleSetDrum:   "Drum",
+}
+
+var curveTypeLetters = map[CurveType]string{
+	CurveTypeCatmull: "C",
+	CurveTypeBezier:  "B",
+	CurveTypeLinear:  "L",
+	CurveTypePerfect: "P",
+}
+
+var timeSignatureMeters = map[TimeSignature]int{
+	TimeSignatureQuadruple: 4,
+	TimeSignatureTriplet:   3,
+	TimeSignature5:         5,
+	TimeSignature6:         6,
+	TimeSignature7:         7,
+}
+
+func formatInt(value int32) string {
+	return strconv.FormatInt(int64(value), 10)
+}
+
+func formatDouble(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatBool(value bool) string {
+	if value {
+		return "1"
+	}
+
+	return "0"
+}
+
+func formatBookmarks(bookmarks []int32) string {
+	bookmarkStrings := make([]string, len(bookmarks))
+
+	for i, bookmark := range bookmarks {
+		bookmarkStrings[i] = formatInt(bookmark)
+	}
+
+	return strings.Join(bookmarkStrings, ",")
+}
+
+func formatColour(colour Color) string {
+	return fmt.Sprintf("%d,%d,%d", colour.R, colour.G, colour.B)
+}
+
+func (osuFile *OsuFile) generalValues() []keyValue {
+	general := &osuFile.General
+
+	values := []keyValue{
+		{"AudioFilename", general.AudioFilename},
+		{"AudioLeadIn", formatInt(general.AudioLeadIn)},
+	}
+
+	if len(general.AudioHash) != 0 {
+		values = append(values, keyValue{"AudioHash", general.AudioHash})
+	}
+
+	values = append(values,
+		keyValue{"PreviewTime", formatInt(general.PreviewTime)},
+		keyValue{"Countdown", formatInt(general.Countdown)},
+	)
+
+	//There's no name for SampleSetNone, leaving it out keeps it at None
+	if sampleSetName, ok := sampleSetNames[general.SampleSet]; ok {
+		values = append(values, keyValue{"SampleSet", sampleSetName})
+	}
+
+	values = append(values,
+		keyValue{"StackLeniency", formatDouble(general.StackLeniency)},
+		keyValue{"Mode", formatInt(int32(general.Mode))},
+		keyValue{"LetterboxInBreaks", formatBool(general.LetterboxInBreaks)},
+	)
+
+	if general.StoryFireInFront {
+		values = append(values, keyValue{"StoryFireInFront", formatBool(general.StoryFireInFront)})
+	}
+
+	values = append(values, keyValue{"UseSkinSprites", formatBool(general.UseSkinSprites)})
+
+	if general.AlwaysShowPlayfield {
+		values = append(values, keyValue{"AlwaysShowPlayfield", formatBool(general.AlwaysShowPlayfield)})
+	}
+
+	values = append(values,
+		keyValue{"EpilepsyWarning", formatBool(general.EpilepsyWarning)},
+		keyValue{"CountdownOffset", formatInt(general.CountdownOffset)},
+		keyValue{"WidescreenStoryboard", formatBool(general.WidescreenStoryboard)},
+		keyValue{"SamplesMatchPlaybackRate", formatBool(general.SamplesMatchPlaybackRate)},
+	)
+
+	if general.SampleVolume != 0 {
+		values = append(values, keyValue{"SampleVolume", formatInt(general.SampleVolume)})
+	}
+
+	if len(general.SkinPreference) != 0 {
+		values = append(values, keyValue{"SkinPreference", general.SkinPreference})
+	}
+
+	//Pre v14 files kept editor settings in [General]
+	if len(general.EditorBookmarks) != 0 {
+		values = append(values, keyValue{"EditorBookmarks", formatBookmarks(general.EditorBookmarks)})
+	}
+
+	if general.EditorDistanceSpacing != 0 {
+		values = append(values, keyValue{"EditorDistanceSpacing", formatDouble(general.EditorDistanceSpacing)})
+	}
+
+	if general.TimelineZoom != 0 {
+		values = append(values, keyValue{"TimelineZoom", formatDouble(general.TimelineZoom)})
+	}
+
+	return values
+}
+
+func (osuFile *OsuFile) editorValues() []keyValue {
+	editor := &osuFile.Editor
+
+	values := []keyValue{}
+
+	if len(editor.Bookmarks) != 0 {
+		values = append(values, keyValue{"Bookmarks", formatBookmarks(editor.Bookmarks)})
+	}
+
+	values = append(values,
+		keyValue{"DistanceSpacing", formatDouble(editor.DistanceSpacing)},
+		keyValue{"BeatDivisor", formatInt(editor.BeatDivisor)},
+		keyValue{"GridSize", formatInt(editor.GridSize)},
+	)
+
+	if editor.TimelineZoom != 0 {
+		values = append(values, keyValue{"TimelineZoom", formatDouble(editor.TimelineZoom)})
+	}
+
+	return values
+}
+
+func (osuFile *OsuFile) metadataValues() []keyValue {
+	metadata := &osuFile.Metadata
+
+	return []keyValue{
+		{"Title", metadata.Title},
+		{"TitleUnicode", metadata.TitleUnicode},
+		{"Artist", metadata.Artist},
+		{"ArtistUnicode", metadata.ArtistUnicode},
+		{"Creator", metadata.Creator},
+		{"Version", metadata.Version},
+		{"Source", metadata.Source},
+		{"Tags", metadata.Tags},
+		{"BeatmapID", formatInt(metadata.BeatmapID)},
+		{"BeatmapSetID", formatInt(metadata.BeatmapSetID)},
+	}
+}
+
+func (osuFile *OsuFile) difficultyValues() []keyValue {
+	difficulty := &osuFile.Difficulty
+
+	return []keyValue{
+		{"HPDrainRate", formatDouble(difficulty.HPDrainRate)},
+		{"CircleSize", formatDouble(difficulty.CircleSize)},
+		{"OverallDifficulty", formatDouble(difficulty.OverallDifficulty)},
+		{"ApproachRate", formatDouble(difficulty.ApproachRate)},
+		{"SliderMultiplier", formatDouble(difficulty.SliderMultiplier)},
+		{"SliderTickRate", formatDouble(difficulty.SliderTickRate)},
+	}
+}
+
+func (osuFile *OsuFile) colourValues() []keyValue {
+	colours := &osuFile.Colours
+
+	values := []keyValue{}
+
+	for i, colour := range colours.Combos {
+		values = append(values, keyValue{fmt.Sprintf("Combo%d", i+1), formatColour(colour)})
+	}
+
+	if colours.SliderTrackOverride != nil {
+		values = append(values, keyValue{"SliderTrackOverride", formatColour(*colours.SliderTrackOverride)})
+	}
+
+	if colours.SliderBorder != nil {
+		values = append(values, keyValue{"SliderBorder", formatColour(*colours.SliderBorder)})
+	}
+
+	return values
+}
+
+func encodeEvent(event Event) string {
+	switch event.EventType {
+	case EventTypeBackground:
+		return fmt.Sprintf("0,%d,%s,0,0", event.EventTime, event.BackgroundImage)
+	case EventTypeVideo:
+		return fmt.Sprintf("Video,%d,%s", event.EventTime, event.BackgroundImage)
+	case EventTypeBreak:
+		return fmt.Sprintf("2,%d,%d", event.BreakTimeBegin, event.BreakTimeEnd)
+	case EventTypeColor:
+		return fmt.Sprintf("3,%d,%d,%d,%d", event.EventTime, event.Colour.R, event.Colour.G, event.Colour.B)
+	}
+
+	return ""
+}
+
+func encodeCommandValues(values []float64) string {
+	valueStrings := make([]string, len(values))
+
+	for i, value := range values {
+		valueStrings[i] = formatDouble(value)
+	}
+
+	return strings.Join(valueStrings, ",")
+}
+
+func encodeStoryboardCommand(command StoryboardCommand, depth int) []string {
+	indent := strings.Repeat(" ", depth)
+	commandName := ""
+
+	for name, commandType := range commandTypeNames {
+		if commandType == command.Type {
+			commandName = name
+		}
+	}
+
+	switch command.Type {
+	case CommandTypeLoop, CommandTypeTrigger:
+		lines := []string{}
+
+		if command.Type == CommandTypeLoop {
+			lines = append(lines, fmt.Sprintf("%sL,%d,%d", indent, command.StartTime, command.LoopCount))
+		} else if command.TriggerGroup != 0 {
+			lines = append(lines, fmt.Sprintf("%sT,%s,%d,%d,%d", indent, command.TriggerName, command.StartTime, command.EndTime, command.TriggerGroup))
+		} else {
+			lines = append(lines, fmt.Sprintf("%sT,%s,%d,%d", indent, command.TriggerName, command.StartTime, command.EndTime))
+		}
+
+		for _, child := range command.Commands {
+			lines = append(lines, encodeStoryboardCommand(child, depth+1)...)
+		}
+
+		return lines
+	case CommandTypeParameter:
+		return []string{fmt.Sprintf("%sP,%d,%d,%d,%s", indent, command.Easing, command.StartTime, command.EndTime, command.Parameter)}
+	}
+
+	values := encodeCommandValues(command.StartValues)
+	changing := false
+
+	for i := range command.StartValues {
+		if i >= len(command.EndValues) || command.StartValues[i] != command.EndValues[i] {
+			changing = true
+		}
+	}
+
+	//Commands which don't change their value only need a single set of values
+	if changing {
+		values += "," + encodeCommandValues(command.EndValues)
+	}
+
+	return []string{fmt.Sprintf("%s%s,%d,%d,%d,%s", indent, commandName, command.Easing, command.StartTime, command.EndTime, values)}
+}
+
+func encodeStoryboardObject(object StoryboardObject) []string {
+	layerName := ""
+	originName := ""
+
+	for name, layer := range storyboardLayerNames {
+		if layer == object.Layer {
+			layerName = name
+		}
+	}
+
+	for name, origin := range storyboardOriginNames {
+		if origin == object.Origin {
+			originName = name
+		}
+	}
+
+	lines := []string{}
+
+	switch object.Type {
+	case EventTypeSample:
+		return []string{fmt.Sprintf("Sample,%d,%d,\"%s\",%d", object.Time, object.Layer, object.FilePath, object.Volume)}
+	case EventTypeAnimation:
+		loopType := "LoopForever"
+
+		if object.LoopType == AnimationLoopOnce {
+			loopType = "LoopOnce"
+		}
+
+		lines = append(lines, fmt.Sprintf("Animation,%s,%s,\"%s\",%s,%s,%d,%s,%s", layerName, originName, object.FilePath, formatDouble(object.Position.X), formatDouble(object.Position.Y), object.FrameCount, formatDouble(object.FrameDelay), loopType))
+	default:
+		lines = append(lines, fmt.Sprintf("Sprite,%s,%s,\"%s\",%s,%s", layerName, originName, object.FilePath, formatDouble(object.Position.X), formatDouble(object.Position.Y)))
+	}
+
+	for _, command := range object.Commands {
+		lines = append(lines, encodeStoryboardCommand(command, 1)...)
+	}
+
+	return lines
+}
+
+// Storyboard objects get written grouped by layer, the same way the editor does it
+func encodeStoryboard(storyboard Storyboard) []string {
+	lines := []string{}
+
+	layerComments := []string{
+		"//Storyboard Layer 0 (Background)",
+		"//Storyboard Layer 1 (Fail)",
+		"//Storyboard Layer 2 (Pass)",
+		"//Storyboard Layer 3 (Foreground)",
+		"//Storyboard Layer 4 (Overlay)",
+	}
+
+	for layer, comment := range layerComments {
+		lines = append(lines, comment)
+
+		for _, object := range storyboard.Objects {
+			if object.Type != EventTypeSample && object.Layer == StoryboardLayer(layer) {
+				lines = append(lines, encodeStoryboardObject(object)...)
+			}
+		}
+	}
+
+	lines = append(lines, "//Storyboard Sound Samples")
+
+	for _, object := range storyboard.Objects {
+		if object.Type == EventTypeSample {
+			lines = append(lines, encodeStoryboardObject(object)...)
+		}
+	}
+
+	return lines
+}
+
+func (osuFile *OsuFile) eventLines() []string {
+	lines := []string{"//Background and Video events"}
+
+	for _, event := range osuFile.Events.Events {
+		if event.EventType == EventTypeBackground || event.EventType == EventTypeVideo {
+			lines = append(lines, encodeEvent(event))
+		}
+	}
+
+	lines = append(lines, "//Break Periods")
+
+	for _, event := range osuFile.Events.Events {
+		if event.EventType == EventTypeBreak {
+			lines = append(lines, encodeEvent(event))
+		}
+	}
+
+	lines = append(lines, encodeStoryboard(osuFile.Events.Storyboard)...)
+	lines = append(lines, "//Background Colour Transformations")
+
+	for _, event := range osuFile.Events.Events {
+		if event.EventType == EventTypeColor {
+			lines = append(lines, encodeEvent(event))
+		}
+	}
+
+	return lines
+}
+
+func encodeTimingPoint(timingPoint TimingPoint) string {
+	return fmt.Sprintf(
+		"%s,%s,%d,%d,%d,%d,%s,%d",
+		formatDouble(timingPoint.Offset),
+		formatDouble(timingPoint.BeatLength),
+		timeSignatureMeters[timingPoint.TimeSignature],
+		timingPoint.SampleSet,
+		timingPoint.CustomSampleSet,
+		timingPoint.Volume,
+		formatBool(!timingPoint.InheritedTimingPoint),
+		timingPoint.SpecialFlag,
+	)
+}
+
+func encodeHitSample(hitObject HitObject) string {
+	return fmt.Sprintf("%d:%d:%d:%d:%s", hitObject.SampleSet, hitObject.SampleSetAddition, hitObject.CustomSampleSet, hitObject.Volume, hitObject.SampleFile)
+}
+
+func encodeHitObject(hitObject HitObject) string {
+	hitObjectType := int32(hitObject.Type) | int32(hitObject.ComboColorOffset&7)<<4
+
+	if hitObject.NewCombo {
+		hitObjectType |= int32(HitObjectTypeNewCombo)
+	}
+
+	common := fmt.Sprintf("%s,%s,%s,%d,%d", formatDouble(hitObject.Position.X), formatDouble(hitObject.Position.Y), formatDouble(hitObject.Time), hitObjectType, hitObject.HitSound)
+
+	switch hitObject.Type {
+	case HitObjectTypeSlider:
+		curve := []string{curveTypeLetters[hitObject.CurveType]}
+
+		for _, point := range hitObject.SliderPoints {
+			curve = append(curve, formatDouble(point.X)+":"+formatDouble(point.Y))
+		}
+
+		edgeSounds := make([]string, len(hitObject.SoundTypes))
+
+		for i, sound := range hitObject.SoundTypes {
+			edgeSounds[i] = strconv.Itoa(int(sound))
+		}
+
+		edgeSets := make([]string, len(hitObject.SampleSets))
+
+		for i, sampleSet := range hitObject.SampleSets {
+			sampleSetAddition := SampleSetNone
+
+			if i < len(hitObject.SampleSetAdditions) {
+				sampleSetAddition = hitObject.SampleSetAdditions[i]
+			}
+
+			edgeSets[i] = fmt.Sprintf("%d:%d", sampleSet, sampleSetAddition)
+		}
+
+		//Older files leave out the edge sets and hit sample entirely
+		if len(edgeSets) == 0 && encodeHitSample(hitObject) == encodeHitSample(HitObject{}) {
+			return fmt.Sprintf("%s,%s,%d,%s,%s", common, strings.Join(curve, "|"), hitObject.RepeatCount, formatDouble(hitObject.SliderLength), strings.Join(edgeSounds, "|"))
+		}
+
+		return fmt.Sprintf("%s,%s,%d,%s,%s,%s,%s", common, strings.Join(curve, "|"), hitObject.RepeatCount, formatDouble(hitObject.SliderLength), strings.Join(edgeSounds, "|"), strings.Join(edgeSets, "|"), encodeHitSample(hitObject))
+	case HitObjectTypeSpinner:
+		return fmt.Sprintf("%s,%d,%s", common, hitObject.EndTime, encodeHitSample(hitObject))
+	case HitObjectTypeHold:
+		return fmt.Sprintf("%s,%d:%s", common, hitObject.EndTime, encodeHitSample(hitObject))
+	}
+
+	return fmt.Sprintf("%s,%s", common, encodeHitSample(hitObject))
+}
+
+func writeKeyValues(builder *strings.Builder, section string, separator string, values []keyValue) {
+	builder.WriteString(section + "\r\n")
+
+	for _, pair := range values {
+		builder.WriteString(pair.key + separator + pair.value + "\r\n")
+	}
+
+	builder.WriteString("\r\n")
+}
+
+func writeLines(builder *strings.Builder, section string, lines []string) {
+	builder.WriteString(section + "\r\n")
+
+	for _, line := range lines {
+		builder.WriteString(line + "\r\n")
+	}
+
+	builder.WriteString("\r\n")
+}
+
+// Encodes the beatmap as osu file format v14 text
+func (osuFile *OsuFile) Encode() string {
+	builder := strings.Builder{}
+
+	builder.WriteString(fmt.Sprintf("osu file format v%d\r\n\r\n", EncoderVersion))
+
+	writeKeyValues(&builder, "[General]", ": ", osuFile.generalValues())
+	writeKeyValues(&builder, "[Editor]", ": ", osuFile.editorValues())
+	writeKeyValues(&builder, "[Metadata]", ":", osuFile.metadataValues())
+	writeKeyValues(&builder, "[Difficulty]", ":", osuFile.difficultyValues())
+
+	writeLines(&builder, "[Events]", osuFile.eventLines())
+
+	timingPoints := make([]string, len(osuFile.TimingPoints.TimingPoints))
+
+	for i, timingPoint := range osuFile.TimingPoints.TimingPoints {
+		timingPoints[i] = encodeTimingPoint(timingPoint)
+	}
+
+	writeLines(&builder, "[TimingPoints]", timingPoints)
+
+	if colours := osuFile.colourValues(); len(colours) != 0 {
+		writeKeyValues(&builder, "[Colours]", " : ", colours)
+	}
+
+	hitObjects := make([]string, len(osuFile.HitObjects.List))
+
+	for i, hitObject := range osuFile.HitObjects.List {
+		hitObjects[i] = encodeHitObject(hitObject)
+	}
+
+	builder.WriteString("[HitObjects]\r\n")
+
+	for _, hitObject := range hitObjects {
+		builder.WriteString(hitObject + "\r\n")
+	}
+
+	return builder.String()
+}
+
+// Writes the beatmap as osu file format v14 text
+func (osuFile *OsuFile) WriteTo(writer io.Writer) (int64, error) {
+	written, err := io.WriteString(writer, osuFile.Encode())
+
+	return int64(written), err
+}
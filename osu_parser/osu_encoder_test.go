@@ -0,0 +1,92 @@
+package osu_parser_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func assertRoundTrip(t *testing.T, name string, parsedOsuFile osu_parser.OsuFile) {
+	encoded := parsedOsuFile.Encode()
+	reparsedOsuFile, err := osu_parser.ParseText(encoded)
+
+	if err != nil {
+		t.Fatalf("%s: failed to parse encoded file: %s", name, err)
+	}
+
+	if len(reparsedOsuFile.Diagnostics) != 0 {
+		t.Fatalf("%s: encoded file has warnings: %v", name, reparsedOsuFile.Diagnostics)
+	}
+
+	//Files of every version get written as the version the encoder knows
+	if reparsedOsuFile.Version != osu_parser.EncoderVersion {
+		t.Fatalf("%s: expected the encoded file to be v%d, got v%d", name, osu_parser.EncoderVersion, reparsedOsuFile.Version)
+	}
+
+	//Other than the version only the hash of the text changes
+	expected := parsedOsuFile
+	expected.Version = osu_parser.EncoderVersion
+	expected.Md5Hash = reparsedOsuFile.Md5Hash
+
+	if !reflect.DeepEqual(expected, reparsedOsuFile) {
+		t.Fatalf("%s: round trip mismatch\n%+v\n%+v", name, expected, reparsedOsuFile)
+	}
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+	cases, _ := filepath.Glob("../cases/*.osu")
+
+	if len(cases) == 0 {
+		t.Fatal("no test cases found")
+	}
+
+	for _, filename := range cases {
+		parsedOsuFile, err := osu_parser.ParseFile(filename)
+
+		if err != nil {
+			t.Fatalf("%s: %s", filename, err)
+		}
+
+		assertRoundTrip(t, filename, parsedOsuFile)
+	}
+}
+
+func TestEncoderRoundTripStoryboard(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(storyboardTestText)
+
+	assertRoundTrip(t, "storyboard", parsedOsuFile)
+}
+
+func TestEncoderRoundTripColourEvents(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[Events]\n0,0,\"bg.jpg\",0,0\n2,1000,2000\n3,100,163,162,255\n")
+
+	expected := []osu_parser.Event{
+		{EventType: osu_parser.EventTypeBackground, BackgroundImage: "\"bg.jpg\""},
+		{EventType: osu_parser.EventTypeBreak, BreakTimeBegin: 1000, BreakTimeEnd: 2000},
+		{EventType: osu_parser.EventTypeColor, EventTime: 100, Colour: osu_parser.Color{R: 163, G: 162, B: 255}},
+	}
+
+	if !reflect.DeepEqual(parsedOsuFile.Events.Events, expected) {
+		t.Fatalf("expected events %+v, got %+v", expected, parsedOsuFile.Events.Events)
+	}
+
+	assertRoundTrip(t, "colour events", parsedOsuFile)
+}
+
+func TestEncoderWriteTo(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	buffer := bytes.Buffer{}
+	written, err := parsedOsuFile.WriteTo(&buffer)
+
+	if err != nil || written != int64(buffer.Len()) || buffer.String() != parsedOsuFile.Encode() {
+		t.Fail()
+	}
+
+	if !bytes.HasPrefix(buffer.Bytes(), []byte("osu file format v14\r\n")) {
+		t.Fail()
+	}
+}
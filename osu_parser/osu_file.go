@@ -1,11 +1,15 @@
 package osu_parser
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"math"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -20,90 +24,211 @@ type OsuFile struct {
 	Difficulty   DifficultySection
 	Events       EventsSection
 	TimingPoints TimingPointSection
+	Colours      ColoursSection
 	HitObjects   HitObjectsSection
 
 	Length      int64
 	DrainLength int64
 	FirstBpm    float64
 
-	ParserWarnings []string
+	Diagnostics []Diagnostic
 }
 
 func ParseFile(filename string) (OsuFile, error) {
-	data, err := os.ReadFile(filename)
+	file, err := os.Open(filename)
 
 	if err != nil {
 		return OsuFile{}, err
 	}
 
-	return ParseBytes(data)
+	defer file.Close()
+
+	return ParseReader(file)
 }
 
-func ParseBytes(bytes []byte) (OsuFile, error) {
-	return ParseText(string(bytes))
+func ParseBytes(data []byte) (OsuFile, error) {
+	return ParseReader(bytes.NewReader(data))
 }
 
+type Section int32
+
 const (
-	SectionGeneral      = 0
-	SectionEditor       = 1
-	SectionMetadata     = 2
-	SectionDifficulty   = 3
-	SectionEvents       = 4
-	SectionTimingPoints = 5
-	SectionHitObjects   = 6
-	SectionIgnore       = 7
+	SectionGeneral      Section = 0
+	SectionEditor       Section = 1
+	SectionMetadata     Section = 2
+	SectionDifficulty   Section = 3
+	SectionEvents       Section = 4
+	SectionTimingPoints Section = 5
+	SectionHitObjects   Section = 6
+	SectionIgnore       Section = 7
+	SectionColours      Section = 8
+	SectionVariables    Section = 9
 )
 
+var sectionHeaders = map[string]Section{
+	"[General]":      SectionGeneral,
+	"[Editor]":       SectionEditor,
+	"[Metadata]":     SectionMetadata,
+	"[Difficulty]":   SectionDifficulty,
+	"[Events]":       SectionEvents,
+	"[TimingPoints]": SectionTimingPoints,
+	"[HitObjects]":   SectionHitObjects,
+	"[Colours]":      SectionColours,
+	"[Variables]":    SectionVariables,
+}
+
 func ParseText(osuText string) (OsuFile, error) {
-	hashed := md5.Sum([]byte(osuText))
-	hashedHex := hex.EncodeToString(hashed[:])
+	return ParseReader(strings.NewReader(osuText))
+}
 
-	returnOsuFile := OsuFile{
-		Md5Hash: hashedHex,
-	}
+// Parses line by line as the data comes in, the whole file is never held in memory at once
+func ParseReader(reader io.Reader) (OsuFile, error) {
+	return parseReader(reader, ParseOptions{}, nil)
+}
 
-	osuText = strings.ReplaceAll(osuText, "\r", "")
+// Removes the characters the parser ignores anywhere in a line, lossless mode has to see lines the same way
+func cleanLine(line string) string {
+	line = strings.ReplaceAll(line, "\r", "")
 	//what the fuck, "Maeken Trance Project - Koi no Maiahi - Insane.osu" does this for some reason
-	osuText = strings.ReplaceAll(osuText, "\ufeff", "")
+	return strings.ReplaceAll(line, "\ufeff", "")
+}
+
+// Reads the next line the way the parser sees it, lines longer than maxLineLength are an error if it's set
+func readCleanLine(lineReader *bufio.Reader, maxLineLength int) (string, error) {
+	lineBytes := []byte{}
+
+	for {
+		chunk, err := lineReader.ReadSlice('\n')
+		lineBytes = append(lineBytes, chunk...)
+
+		//Stop reading before an overly long line is held in memory in its entirety
+		if maxLineLength > 0 && len(bytes.TrimRight(lineBytes, "\r\n")) > maxLineLength {
+			return "", fmt.Errorf("%w: line longer than %d characters", ErrLimitExceeded, maxLineLength)
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		return cleanLine(strings.TrimSuffix(string(lineBytes), "\n")), err
+	}
+}
+
+func parseReader(reader io.Reader, options ParseOptions, sourceMap *sourceMap) (OsuFile, error) {
+	//Everything read goes through the hash, so it's complete once the last line was read
+	hash := md5.New()
+	lineReader := bufio.NewReader(io.TeeReader(reader, hash))
+
+	returnOsuFile := OsuFile{}
+
+	firstLine, readErr := readCleanLine(lineReader, options.MaxLineLength)
+
+	if readErr != nil && readErr != io.EOF {
+		return OsuFile{}, readErr
+	}
 
-	lines := strings.Split(osuText, "\n")
 	currentSection := SectionGeneral
 
-	version := strings.Replace(lines[0], "osu file format v", "", -1)
+	version := strings.Replace(firstLine, "osu file format v", "", -1)
 	versionParsed, verionParseErr := strconv.ParseInt(version, 10, 64)
 
 	if verionParseErr != nil {
-		return OsuFile{}, verionParseErr
+		return OsuFile{}, fmt.Errorf("%w: %w", ErrInvalidVersion, verionParseErr)
 	}
 
 	returnOsuFile.Version = int32(versionParsed)
 
-	addWarning := func(line int, key string, err string) {
-		returnOsuFile.ParserWarnings = append(returnOsuFile.ParserWarnings, fmt.Sprintf("Line %d: Error Parsing %s: %s", line, key, err))
+	diagnostics := &diagnosticCollector{
+		diagnostics: &returnOsuFile.Diagnostics,
+	}
+
+	parseInt := diagnostics.parseInt
+	parseDouble := diagnostics.parseDouble
+
+	parseBookmarks := func(line int, key string, value string, offset int) []int32 {
+		bookmarks := []int32{}
+
+		if len(value) == 0 {
+			return bookmarks
+		}
+
+		bookmarkStrings, bookmarkOffsets := splitOffsets(value, offset, ",")
+
+		for j, bookmarkString := range bookmarkStrings {
+			bookmark := int32(0)
+			bookmarkString, bookmarkOffset := trimOffset(bookmarkString, bookmarkOffsets[j], " ")
+
+			parseInt(line, key, bookmarkString, bookmarkOffset, &bookmark)
+
+			bookmarks = append(bookmarks, bookmark)
+		}
+
+		return bookmarks
 	}
 
-	parseInt := func(line int, key string, value string, ret *int32) {
-		parsed, parseErr := strconv.ParseInt(value, 10, 64)
+	parseColour := func(line int, key string, value string, offset int) (Color, bool) {
+		split, offsets := splitOffsets(value, offset, ",")
+
+		if len(split) < 3 {
+			diagnostics.error(line, key, value, offset, fmt.Errorf("%w of colour", ErrIncorrectFormatting))
+
+			return Color{}, false
+		}
+
+		colour := Color{}
+		components := []*int32{&colour.R, &colour.G, &colour.B}
+
+		for j, component := range components {
+			componentString, componentOffset := trimOffset(split[j], offsets[j], " ")
 
-		if parseErr != nil {
-			addWarning(line, key, parseErr.Error())
+			parseInt(line, key, componentString, componentOffset, component)
 		}
 
-		*ret = int32(parsed)
+		return colour, true
 	}
 
-	parseDouble := func(line int, key string, value string, ret *float64) {
-		parsed, parseErr := strconv.ParseFloat(value, 64)
+	storyboard := newStoryboardParser(&returnOsuFile.Events.Storyboard, diagnostics)
+	storyboard.maxLineLength = options.MaxLineLength
+
+	//Combo numbers, in the same order as Colours.Combos
+	comboNumbers := []int{}
+
+	//Files older than v8 don't have an approach rate, it's the same as overall difficulty there
+	approachRateSet := false
+
+	//Line every hit object came from, for diagnostics about them after everything was read
+	hitObjectLines := []int{}
 
-		if parseErr != nil {
-			addWarning(line, key, parseErr.Error())
+	//Checks whatever the previous line might've caused
+	checkLimits := func() error {
+		if err := options.checkStoryboardLimits(returnOsuFile.Diagnostics, storyboard); err != nil {
+			return err
 		}
 
-		*ret = parsed
+		if options.MaxHitObjects > 0 && len(returnOsuFile.HitObjects.List) > options.MaxHitObjects {
+			return fmt.Errorf("%w: more than %d hit objects", ErrLimitExceeded, options.MaxHitObjects)
+		}
+
+		return nil
 	}
 
-	for i := 1; i != len(lines); i++ {
-		line := strings.Trim(lines[i], "\t\r ")
+	for i := 1; readErr == nil; i++ {
+		if limitErr := checkLimits(); limitErr != nil {
+			return OsuFile{}, limitErr
+		}
+
+		rawLine := ""
+		rawLine, readErr = readCleanLine(lineReader, options.MaxLineLength)
+
+		if readErr != nil && readErr != io.EOF {
+			return OsuFile{}, readErr
+		}
+
+		line := strings.Trim(rawLine, "\t\r ")
+		//Where line starts in rawLine, diagnostics point at values by their offset in the raw line
+		lineOffset := len(rawLine) - len(strings.TrimLeft(rawLine, "\t\r "))
+
+		diagnostics.currentLine = rawLine
 
 		if len(line) == 0 {
 			continue
@@ -113,43 +238,42 @@ func ParseText(osuText string) (OsuFile, error) {
 			continue
 		}
 
-		switch line {
-		case "[General]":
-			currentSection = SectionGeneral
-			continue
-		case "[Editor]":
-			currentSection = SectionEditor
-			continue
-		case "[Metadata]":
-			currentSection = SectionMetadata
-			continue
-		case "[Difficulty]":
-			currentSection = SectionDifficulty
-			continue
-		case "[Events]":
-			currentSection = SectionEvents
-			continue
-		case "[TimingPoints]":
-			currentSection = SectionTimingPoints
+		if section, ok := sectionHeaders[line]; ok {
+			if options.stopsAt(section) {
+				//Nothing else gets parsed, but the hash still needs the rest of the input
+				if _, drainErr := io.Copy(io.Discard, lineReader); drainErr != nil {
+					return OsuFile{}, drainErr
+				}
+
+				break
+			}
+
+			currentSection = section
+			diagnostics.section = line
 			continue
-		case "[HitObjects]":
-			currentSection = SectionHitObjects
+		}
+
+		if options.skipsSection(currentSection) {
 			continue
-		case "[Colours]":
-			currentSection = SectionIgnore
 		}
 
 		key := ""
+		keyOffset := lineOffset
 		value := ""
+		valueOffset := -1
 
-		splitKv := strings.Split(line, ":")
+		splitKv := strings.SplitN(line, ":", 2)
 
 		if len(splitKv) > 0 {
-			key = splitKv[0]
+			key, keyOffset = trimOffset(splitKv[0], lineOffset, " ")
 		}
 
 		if len(splitKv) > 1 {
-			value = strings.Trim(splitKv[1], " ")
+			value, valueOffset = trimOffset(splitKv[1], lineOffset+len(splitKv[0])+1, " ")
+		}
+
+		if sourceMap != nil {
+			sourceMap.recordLine(i, key, &returnOsuFile)
 		}
 
 		switch currentSection {
@@ -162,11 +286,11 @@ func ParseText(osuText string) (OsuFile, error) {
 			case "AudioFilename":
 				general.AudioFilename = value
 			case "AudioLeadIn":
-				parseInt(i, key, value, &general.AudioLeadIn)
+				parseInt(i, key, value, valueOffset, &general.AudioLeadIn)
 			case "AudioHash":
 				general.AudioHash = value
 			case "PreviewTime":
-				parseInt(i, key, value, &general.PreviewTime)
+				parseInt(i, key, value, valueOffset, &general.PreviewTime)
 			case "SampleSet":
 				switch value {
 				case "Normal":
@@ -177,11 +301,11 @@ func ParseText(osuText string) (OsuFile, error) {
 					general.SampleSet = SampleSetDrum
 				}
 			case "StackLeniency":
-				parseDouble(i, key, value, &general.StackLeniency)
+				parseDouble(i, key, value, valueOffset, &general.StackLeniency)
 			case "Mode":
 				mode := int32(0)
 
-				parseInt(i, key, value, &mode)
+				parseInt(i, key, value, valueOffset, &mode)
 
 				general.Mode = Playmode(mode)
 			case "LetterboxInBreaks":
@@ -194,29 +318,39 @@ func ParseText(osuText string) (OsuFile, error) {
 				general.EpilepsyWarning = value == "1"
 			case "SamplesMatchPlaybackRate":
 				general.SamplesMatchPlaybackRate = value == "1"
+			case "StoryFireInFront":
+				general.StoryFireInFront = value == "1"
+			case "UseSkinSprites":
+				general.UseSkinSprites = value == "1"
+			case "EditorBookmarks":
+				general.EditorBookmarks = parseBookmarks(i, key, value, valueOffset)
+			case "EditorDistanceSpacing":
+				parseDouble(i, key, value, valueOffset, &general.EditorDistanceSpacing)
 			case "Countdown":
-				parseInt(i, key, value, &general.Countdown)
+				parseInt(i, key, value, valueOffset, &general.Countdown)
 			case "CountdownOffset":
-				parseInt(i, key, value, &general.CountdownOffset)
+				parseInt(i, key, value, valueOffset, &general.CountdownOffset)
 			case "SampleVolume":
-				parseInt(i, key, value, &general.SampleVolume)
+				parseInt(i, key, value, valueOffset, &general.SampleVolume)
 			case "SkinPreference":
 				general.SkinPreference = value
 			case "TimelineZoom":
-				parseDouble(i, key, value, &general.TimelineZoom)
+				parseDouble(i, key, value, valueOffset, &general.TimelineZoom)
 			}
 		case SectionEditor:
 			editor := &returnOsuFile.Editor
 
 			switch key {
+			case "Bookmarks":
+				editor.Bookmarks = parseBookmarks(i, key, value, valueOffset)
 			case "DistanceSpacing":
-				parseDouble(i, key, value, &editor.DistanceSpacing)
+				parseDouble(i, key, value, valueOffset, &editor.DistanceSpacing)
 			case "BeatDivisor":
-				parseInt(i, key, value, &editor.BeatDivisor)
+				parseInt(i, key, value, valueOffset, &editor.BeatDivisor)
 			case "GridSize":
-				parseInt(i, key, value, &editor.GridSize)
+				parseInt(i, key, value, valueOffset, &editor.GridSize)
 			case "TimelineZoom":
-				parseDouble(i, key, value, &editor.TimelineZoom)
+				parseDouble(i, key, value, valueOffset, &editor.TimelineZoom)
 			}
 		case SectionMetadata:
 			metadata := &returnOsuFile.Metadata
@@ -239,9 +373,9 @@ func ParseText(osuText string) (OsuFile, error) {
 			case "Source":
 				metadata.Source = value
 			case "BeatmapID":
-				parseInt(i, key, value, &metadata.BeatmapID)
+				parseInt(i, key, value, valueOffset, &metadata.BeatmapID)
 			case "BeatmapSetID":
-				parseInt(i, key, value, &metadata.BeatmapSetID)
+				parseInt(i, key, value, valueOffset, &metadata.BeatmapSetID)
 			}
 		case SectionDifficulty:
 			difficulty := &returnOsuFile.Difficulty
@@ -252,7 +386,7 @@ func ParseText(osuText string) (OsuFile, error) {
 			parsed, parseErr := strconv.ParseFloat(value, 10)
 
 			if parseErr != nil {
-				addWarning(i, key, parseErr.Error())
+				diagnostics.warning(i, key, value, valueOffset, fmt.Errorf("%w: %w", ErrInvalidNumber, parseErr))
 			} else {
 				if returnOsuFile.Version < 13 {
 					actualValue = math.Floor(parsed)
@@ -270,34 +404,77 @@ func ParseText(osuText string) (OsuFile, error) {
 				difficulty.OverallDifficulty = actualValue
 			case "ApproachRate":
 				difficulty.ApproachRate = actualValue
+				approachRateSet = true
 			case "SliderMultiplier":
-				parseDouble(i, key, value, &difficulty.SliderMultiplier)
+				parseDouble(i, key, value, valueOffset, &difficulty.SliderMultiplier)
 			case "SliderTickRate":
-				parseDouble(i, key, value, &difficulty.SliderTickRate)
+				parseDouble(i, key, value, valueOffset, &difficulty.SliderTickRate)
+			}
+		case SectionVariables:
+			storyboard.parseVariable(i, line, lineOffset)
+		case SectionColours:
+			colours := &returnOsuFile.Colours
+
+			switch {
+			case strings.HasPrefix(key, "Combo"):
+				comboNumber := int32(0)
+
+				parseInt(i, key, strings.TrimPrefix(key, "Combo"), keyOffset+len("Combo"), &comboNumber)
+
+				if colour, ok := parseColour(i, key, value, valueOffset); ok {
+					colours.Combos = append(colours.Combos, colour)
+					comboNumbers = append(comboNumbers, int(comboNumber))
+				}
+			case key == "SliderBorder":
+				if colour, ok := parseColour(i, key, value, valueOffset); ok {
+					colours.SliderBorder = &colour
+				}
+			case key == "SliderTrackOverride":
+				if colour, ok := parseColour(i, key, value, valueOffset); ok {
+					colours.SliderTrackOverride = &colour
+				}
 			}
 		case SectionEvents:
 			if len(line) == 0 {
 				continue
 			}
 
+			if storyboard.parseLine(i, rawLine) {
+				if sourceMap != nil {
+					sourceMap.recordStoryboardLine(i)
+				}
+
+				continue
+			}
+
 			events := &returnOsuFile.Events
 
-			split := strings.Split(line, ",")
+			split, offsets := splitOffsets(line, lineOffset, ",")
+
+			if len(split) < 3 {
+				diagnostics.error(i, "[Events]", line, lineOffset, fmt.Errorf("%w of event", ErrIncorrectFormatting))
+				continue
+			}
+
+			eventType, ok := parseEventType(split[0])
+
+			if !ok {
+				diagnostics.error(i, "[Events]", split[0], offsets[0], fmt.Errorf("%w: event type %s", ErrUnknownValue, split[0]))
+				continue
+			}
 
-			eventType := int32(0)
 			time := int32(0)
 
-			parseInt(i, key, split[0], &eventType)
-			parseInt(i, key, split[1], &time)
+			parseInt(i, key, split[1], offsets[1], &time)
 
-			switch EventType(eventType) {
+			switch eventType {
 			case EventTypeVideo:
 				fallthrough
 			case EventTypeBackground:
 				backgroundImage := strings.Trim(split[2], " ")
 
 				events.Events = append(events.Events, Event{
-					EventType:       EventType(eventType),
+					EventType:       eventType,
 					EventTime:       int32(time),
 					BackgroundImage: backgroundImage,
 				})
@@ -305,18 +482,30 @@ func ParseText(osuText string) (OsuFile, error) {
 				breakStart := time
 				breakEnd := int32(0)
 
-				parseInt(i, key, split[2], &breakEnd)
+				parseInt(i, key, split[2], offsets[2], &breakEnd)
 
 				events.Events = append(events.Events, Event{
 					EventType:      EventTypeBreak,
 					BreakTimeBegin: breakStart,
 					BreakTimeEnd:   breakEnd,
 				})
+			case EventTypeColor:
+				colour, ok := parseColour(i, key, strings.Join(split[2:], ","), offsets[2])
+
+				if !ok {
+					continue
+				}
+
+				events.Events = append(events.Events, Event{
+					EventType: EventTypeColor,
+					EventTime: time,
+					Colour:    colour,
+				})
 			}
 		case SectionTimingPoints:
 			timing := &returnOsuFile.TimingPoints
 
-			split := strings.Split(line, ",")
+			split, offsets := splitOffsets(line, lineOffset, ",")
 			lenSplit := len(split)
 
 			if lenSplit > 2 {
@@ -329,13 +518,13 @@ func ParseText(osuText string) (OsuFile, error) {
 				inheritedTimingPoint := false
 				special := SpecialNone
 
-				parseDouble(i, key, split[0], &offset)
-				parseDouble(i, key, split[1], &beatLength)
+				parseDouble(i, key, split[0], offsets[0], &offset)
+				parseDouble(i, key, split[1], offsets[1], &beatLength)
 
 				switch split[2] {
-				case "0":
+				case "0", "4":
 					timeSignature = TimeSignatureQuadruple
-				case "1":
+				case "1", "3":
 					timeSignature = TimeSignatureTriplet
 				case "5":
 					timeSignature = TimeSignature5
@@ -345,19 +534,24 @@ func ParseText(osuText string) (OsuFile, error) {
 					timeSignature = TimeSignature7
 				}
 
+				if lenSplit > 3 {
+					sampleSetInt := int32(0)
+
+					parseInt(i, key, split[3], offsets[3], &sampleSetInt)
+
+					sampleSet = SampleSet(sampleSetInt)
+				}
+
 				if lenSplit > 4 {
-					switch split[4] {
-					case "0":
-						customSampleSet = CustomSampleSetNone
-					case "1":
-						customSampleSet = CustomSampleSet1
-					case "2":
-						customSampleSet = CustomSampleSet2
-					}
+					customSampleSetInt := int32(0)
+
+					parseInt(i, key, split[4], offsets[4], &customSampleSetInt)
+
+					customSampleSet = CustomSampleSet(customSampleSetInt)
 				}
 
 				if lenSplit > 5 {
-					parseInt(i, key, split[5], &sampleVolume)
+					parseInt(i, key, split[5], offsets[5], &sampleVolume)
 				}
 
 				if lenSplit > 6 {
@@ -389,8 +583,8 @@ func ParseText(osuText string) (OsuFile, error) {
 				offset := 0.0
 				beatLength := 0.0
 
-				parseDouble(i, key, split[0], &offset)
-				parseDouble(i, key, split[1], &beatLength)
+				parseDouble(i, key, split[0], offsets[0], &offset)
+				parseDouble(i, key, split[1], offsets[1], &beatLength)
 
 				timing.TimingPoints = append(timing.TimingPoints, TimingPoint{
 					Offset:               offset,
@@ -403,12 +597,17 @@ func ParseText(osuText string) (OsuFile, error) {
 					SpecialFlag:          SpecialNone,
 				})
 			} else {
-				addWarning(i, "[TimingPoints]", "Incorrect formatting of timing point.")
+				diagnostics.error(i, "[TimingPoints]", line, lineOffset, fmt.Errorf("%w of timing point", ErrIncorrectFormatting))
 			}
 		case SectionHitObjects:
 			hitObjects := &returnOsuFile.HitObjects
 
-			split := strings.Split(line, ",")
+			split, offsets := splitOffsets(line, lineOffset, ",")
+
+			if len(split) < 5 {
+				diagnostics.error(i, "HitObjects", line, lineOffset, fmt.Errorf("%w of hit object", ErrIncorrectFormatting))
+				continue
+			}
 
 			posX := 0.0
 			posY := 0.0
@@ -418,11 +617,11 @@ func ParseText(osuText string) (OsuFile, error) {
 			comboColorOffset := int32(0)
 			newCombo := false
 
-			parseDouble(i, "HitObjects", split[0], &posX)
-			parseDouble(i, "HitObjects", split[1], &posY)
-			parseDouble(i, "HitObjects", split[2], &time)
-			parseInt(i, "HitObjects", split[3], &hitObjectTypeInt)
-			parseInt(i, "HitObjects", split[4], &hitSoundInt)
+			parseDouble(i, "HitObjects", split[0], offsets[0], &posX)
+			parseDouble(i, "HitObjects", split[1], offsets[1], &posY)
+			parseDouble(i, "HitObjects", split[2], offsets[2], &time)
+			parseInt(i, "HitObjects", split[3], offsets[3], &hitObjectTypeInt)
+			parseInt(i, "HitObjects", split[4], offsets[4], &hitSoundInt)
 
 			comboColorOffset = (hitObjectTypeInt >> 4) & 7
 			newCombo = (hitObjectTypeInt & 4) > 0
@@ -466,17 +665,19 @@ func ParseText(osuText string) (OsuFile, error) {
 					sample := ""
 
 					if len(split) > 5 && len(split[5]) > 0 {
-						hitSoundsSplit := strings.Split(split[5], ":")
+						hitSoundsSplit, hitSoundsOffsets := splitOffsets(split[5], offsets[5], ":")
 						lenHsSplit := len(hitSoundsSplit)
 
-						parseInt(i, "HitObjects: Per-object hitsounds 0", hitSoundsSplit[0], &sampleSetInt)
-						parseInt(i, "HitObjects: Per-object hitsounds 1", hitSoundsSplit[1], &sampleSetAdditionInt)
+						parseInt(i, "HitObjects: Per-object hitsounds 0", hitSoundsSplit[0], hitSoundsOffsets[0], &sampleSetInt)
+						if lenHsSplit > 1 {
+							parseInt(i, "HitObjects: Per-object hitsounds 1", hitSoundsSplit[1], hitSoundsOffsets[1], &sampleSetAdditionInt)
+						}
 
 						if lenHsSplit > 2 {
-							parseInt(i, "HitObjects: Per-object hitsounds 2", hitSoundsSplit[2], &customSampleSetInt)
+							parseInt(i, "HitObjects: Per-object hitsounds 2", hitSoundsSplit[2], hitSoundsOffsets[2], &customSampleSetInt)
 
 							if lenHsSplit > 3 {
-								parseInt(i, "HitObjects: Per-object hitsounds 3", hitSoundsSplit[3], &volume)
+								parseInt(i, "HitObjects: Per-object hitsounds 3", hitSoundsSplit[3], hitSoundsOffsets[3], &volume)
 
 								if lenHsSplit > 4 {
 									sample = hitSoundsSplit[4]
@@ -508,7 +709,16 @@ func ParseText(osuText string) (OsuFile, error) {
 					repeatCount := int32(0)
 					length := 0.0
 
-					sliderPointsSplit := strings.Split(split[5], "|")
+					if len(split) < 6 {
+						diagnostics.error(i, "HitObjects Slider", line, lineOffset, fmt.Errorf("%w of slider", ErrIncorrectFormatting))
+						continue
+					}
+
+					sliderPointsSplit, sliderPointsOffsets := splitOffsets(split[5], offsets[5], "|")
+
+					if options.MaxSliderPoints > 0 && len(sliderPointsSplit)-1 > options.MaxSliderPoints {
+						return OsuFile{}, fmt.Errorf("%w: slider with more than %d points", ErrLimitExceeded, options.MaxSliderPoints)
+					}
 
 					//Curve type
 					switch sliderPointsSplit[0] {
@@ -525,7 +735,7 @@ func ParseText(osuText string) (OsuFile, error) {
 					sliderPoints := []Vec2{}
 
 					for j := 1; j != len(sliderPointsSplit); j++ {
-						posSplit := strings.Split(sliderPointsSplit[j], ":")
+						posSplit, posOffsets := splitOffsets(sliderPointsSplit[j], sliderPointsOffsets[j], ":")
 
 						if len(posSplit) < 2 {
 							continue
@@ -534,8 +744,8 @@ func ParseText(osuText string) (OsuFile, error) {
 						pointX := 0.0
 						pointY := 0.0
 
-						parseDouble(i, "HitObjects Slider: Slider Points", posSplit[0], &pointX)
-						parseDouble(i, "HitObjects Slider: Slider Points", posSplit[1], &pointY)
+						parseDouble(i, "HitObjects Slider: Slider Points", posSplit[0], posOffsets[0], &pointX)
+						parseDouble(i, "HitObjects Slider: Slider Points", posSplit[1], posOffsets[1], &pointY)
 
 						sliderPoints = append(sliderPoints, Vec2{
 							X: pointX,
@@ -544,27 +754,47 @@ func ParseText(osuText string) (OsuFile, error) {
 					}
 
 					if len(split) > 6 {
-						parseInt(i, "HitObjects Slider: Slider repeat count", split[6], &repeatCount)
+						parseInt(i, "HitObjects Slider: Slider repeat count", split[6], offsets[6], &repeatCount)
+					}
+
+					//Same limit as the game, anything above is either broken or made to bring parsers down
+					if repeatCount < 0 || repeatCount > 9001 {
+						diagnostics.error(i, "HitObjects Slider: Slider repeat count", split[6], offsets[6], fmt.Errorf("%w: slider repeat count out of range", ErrIncorrectFormatting))
+						returnOsuFile.HitObjects.CountSlider--
+
+						continue
 					}
 
 					if len(split) > 7 {
-						parseDouble(i, "HitObjects Slider: Slider length", split[7], &length)
+						parseDouble(i, "HitObjects Slider: Slider length", split[7], offsets[7], &length)
+
+						//The game can't read lengths that don't fit an int either
+						if math.IsNaN(length) || math.Abs(length) > math.MaxInt32 {
+							diagnostics.error(i, "HitObjects Slider: Slider length", split[7], offsets[7], fmt.Errorf("%w: slider length out of range", ErrIncorrectFormatting))
+							returnOsuFile.HitObjects.CountSlider--
+
+							continue
+						}
+
+						if maxLength := options.sliderLength(); maxLength > 0 && math.Abs(length) > maxLength {
+							return OsuFile{}, fmt.Errorf("%w: slider longer than %s osu!pixels", ErrLimitExceeded, formatDouble(maxLength))
+						}
 					}
 
 					hitSounds := []HitSoundType{}
 
 					//Slider hitsounds
 					if len(split) > 8 && len(split[8]) > 0 {
-						additions := strings.Split(split[8], "|")
+						additions, additionOffsets := splitOffsets(split[8], offsets[8], "|")
 						lenAdditions := len(additions)
 
 						if len(additions) > 0 {
 							additionLength := int(math.Min(float64(lenAdditions), float64(repeatCount+1)))
 
-							for j := 0; j != additionLength; j++ {
+							for j := 0; j < additionLength; j++ {
 								sound := int32(0)
 
-								parseInt(i, "HitObjects Slider: Slider per-thing hitsounds", additions[j], &sound)
+								parseInt(i, "HitObjects Slider: Slider per-thing hitsounds", additions[j], additionOffsets[j], &sound)
 
 								hitSounds = append(hitSounds, HitSoundType(sound))
 							}
@@ -579,17 +809,20 @@ func ParseText(osuText string) (OsuFile, error) {
 					sampleSetAdditions := []SampleSet{}
 
 					if len(split) > 9 && len(split[9]) > 0 {
-						sampleSetsSplit := strings.Split(split[9], "|")
+						sampleSetsSplit, sampleSetsOffsets := splitOffsets(split[9], offsets[9], "|")
 
 						if len(sampleSetsSplit) > 1 {
-							for _, element := range sampleSetsSplit {
-								splitSampleSets := strings.Split(element, ":")
+							for j, element := range sampleSetsSplit {
+								splitSampleSets, sampleSetOffsets := splitOffsets(element, sampleSetsOffsets[j], ":")
 
 								sampleSetInt := int32(0)
 								sampleSetAdditionInt := int32(0)
 
-								parseInt(i, "HitObjects Slider: Slider SampleSets", splitSampleSets[0], &sampleSetInt)
-								parseInt(i, "HitObjects Slider: Slider SampleSets", splitSampleSets[1], &sampleSetAdditionInt)
+								parseInt(i, "HitObjects Slider: Slider SampleSets", splitSampleSets[0], sampleSetOffsets[0], &sampleSetInt)
+
+								if len(splitSampleSets) > 1 {
+									parseInt(i, "HitObjects Slider: Slider SampleSets", splitSampleSets[1], sampleSetOffsets[1], &sampleSetAdditionInt)
+								}
 
 								sampleSets = append(sampleSets, SampleSet(sampleSetInt))
 								sampleSetAdditions = append(sampleSetAdditions, SampleSet(sampleSetAdditionInt))
@@ -604,17 +837,19 @@ func ParseText(osuText string) (OsuFile, error) {
 					sample := ""
 
 					if len(split) > 10 {
-						sampleDetailsSplit := strings.Split(split[10], ":")
+						sampleDetailsSplit, sampleDetailsOffsets := splitOffsets(split[10], offsets[10], ":")
 						lenHsSplit := len(sampleDetailsSplit)
 
-						parseInt(i, "HitObjects Slider: Per-object hitsounds 0", sampleDetailsSplit[0], &sampleSetInt)
-						parseInt(i, "HitObjects Slider: Per-object hitsounds 1", sampleDetailsSplit[1], &sampleSetAdditionInt)
+						parseInt(i, "HitObjects Slider: Per-object hitsounds 0", sampleDetailsSplit[0], sampleDetailsOffsets[0], &sampleSetInt)
+						if lenHsSplit > 1 {
+							parseInt(i, "HitObjects Slider: Per-object hitsounds 1", sampleDetailsSplit[1], sampleDetailsOffsets[1], &sampleSetAdditionInt)
+						}
 
 						if lenHsSplit > 2 {
-							parseInt(i, "HitObjects Slider: Per-object hitsounds 2", sampleDetailsSplit[2], &customSampleSetInt)
+							parseInt(i, "HitObjects Slider: Per-object hitsounds 2", sampleDetailsSplit[2], sampleDetailsOffsets[2], &customSampleSetInt)
 
 							if lenHsSplit > 3 {
-								parseInt(i, "HitObjects Slider: Per-object hitsounds 3", sampleDetailsSplit[3], &volume)
+								parseInt(i, "HitObjects Slider: Per-object hitsounds 3", sampleDetailsSplit[3], sampleDetailsOffsets[3], &volume)
 
 								if lenHsSplit > 4 {
 									sample = sampleDetailsSplit[4]
@@ -650,9 +885,14 @@ func ParseText(osuText string) (OsuFile, error) {
 						SampleSetAdditions: sampleSetAdditions,
 					})
 				case HitObjectTypeSpinner:
+					if len(split) < 6 {
+						diagnostics.error(i, "HitObjects Spinner", line, lineOffset, fmt.Errorf("%w of spinner", ErrIncorrectFormatting))
+						continue
+					}
+
 					endTime := int32(0)
 
-					parseInt(i, "HitObjects Spinner: Spinner End time", split[5], &endTime)
+					parseInt(i, "HitObjects Spinner: Spinner End time", split[5], offsets[5], &endTime)
 
 					sampleSetInt := int32(0)
 					sampleSetAdditionInt := int32(0)
@@ -661,17 +901,19 @@ func ParseText(osuText string) (OsuFile, error) {
 					sample := ""
 
 					if len(split) > 6 {
-						sampleDetailsSplit := strings.Split(split[6], ":")
+						sampleDetailsSplit, sampleDetailsOffsets := splitOffsets(split[6], offsets[6], ":")
 						lenHsSplit := len(sampleDetailsSplit)
 
-						parseInt(i, "HitObjects Spinner: Per-object hitsounds 0", sampleDetailsSplit[0], &sampleSetInt)
-						parseInt(i, "HitObjects Spinner: Per-object hitsounds 1", sampleDetailsSplit[1], &sampleSetAdditionInt)
+						parseInt(i, "HitObjects Spinner: Per-object hitsounds 0", sampleDetailsSplit[0], sampleDetailsOffsets[0], &sampleSetInt)
+						if lenHsSplit > 1 {
+							parseInt(i, "HitObjects Spinner: Per-object hitsounds 1", sampleDetailsSplit[1], sampleDetailsOffsets[1], &sampleSetAdditionInt)
+						}
 
 						if lenHsSplit > 2 {
-							parseInt(i, "HitObjects Spinner: Per-object hitsounds 2", sampleDetailsSplit[2], &customSampleSetInt)
+							parseInt(i, "HitObjects Spinner: Per-object hitsounds 2", sampleDetailsSplit[2], sampleDetailsOffsets[2], &customSampleSetInt)
 
 							if lenHsSplit > 3 {
-								parseInt(i, "HitObjects Spinner: Per-object hitsounds 3", sampleDetailsSplit[3], &volume)
+								parseInt(i, "HitObjects Spinner: Per-object hitsounds 3", sampleDetailsSplit[3], sampleDetailsOffsets[3], &volume)
 
 								if lenHsSplit > 4 {
 									sample = sampleDetailsSplit[4]
@@ -707,19 +949,23 @@ func ParseText(osuText string) (OsuFile, error) {
 					endTime := int32(0)
 
 					if len(split) > 5 {
-						sampleDetailsSplit := strings.Split(split[5], ":")
+						sampleDetailsSplit, sampleDetailsOffsets := splitOffsets(split[5], offsets[5], ":")
 						lenHsSplit := len(sampleDetailsSplit)
 
-						parseInt(i, "HitObjects Hold: Hold Endtime", sampleDetailsSplit[1], &endTime)
-						parseInt(i, "HitObjects Hold: Per-object hitsounds 0", sampleDetailsSplit[1], &sampleSetInt)
-						parseInt(i, "HitObjects Hold: Per-object hitsounds 0", sampleDetailsSplit[1], &sampleSetInt)
-						parseInt(i, "HitObjects Hold: Per-object hitsounds 1", sampleDetailsSplit[2], &sampleSetAdditionInt)
+						parseInt(i, "HitObjects Hold: Hold Endtime", sampleDetailsSplit[0], sampleDetailsOffsets[0], &endTime)
+						if lenHsSplit > 1 {
+							parseInt(i, "HitObjects Hold: Per-object hitsounds 0", sampleDetailsSplit[1], sampleDetailsOffsets[1], &sampleSetInt)
+						}
+
+						if lenHsSplit > 2 {
+							parseInt(i, "HitObjects Hold: Per-object hitsounds 1", sampleDetailsSplit[2], sampleDetailsOffsets[2], &sampleSetAdditionInt)
+						}
 
 						if lenHsSplit > 3 {
-							parseInt(i, "HitObjects Hold: Per-object hitsounds 2", sampleDetailsSplit[3], &customSampleSetInt)
+							parseInt(i, "HitObjects Hold: Per-object hitsounds 2", sampleDetailsSplit[3], sampleDetailsOffsets[3], &customSampleSetInt)
 
 							if lenHsSplit > 4 {
-								parseInt(i, "HitObjects Hold: Per-object hitsounds 3", sampleDetailsSplit[4], &volume)
+								parseInt(i, "HitObjects Hold: Per-object hitsounds 3", sampleDetailsSplit[4], sampleDetailsOffsets[4], &volume)
 
 								if lenHsSplit > 5 {
 									sample = sampleDetailsSplit[5]
@@ -749,20 +995,157 @@ func ParseText(osuText string) (OsuFile, error) {
 				}
 			}
 		}
+
+		if sourceMap != nil {
+			sourceMap.recordItems(i, &returnOsuFile)
+		}
+
+		if len(returnOsuFile.HitObjects.List) > len(hitObjectLines) {
+			hitObjectLines = append(hitObjectLines, i)
+		}
+	}
+
+	if limitErr := checkLimits(); limitErr != nil {
+		return OsuFile{}, limitErr
+	}
+
+	//Combo colours can be written in any order, the game goes by their number
+	comboOrder := make([]int, len(comboNumbers))
+
+	for j := range comboOrder {
+		comboOrder[j] = j
+	}
+
+	sort.SliceStable(comboOrder, func(a, b int) bool {
+		return comboNumbers[comboOrder[a]] < comboNumbers[comboOrder[b]]
+	})
+
+	orderedCombos := []Color{}
+
+	for _, index := range comboOrder {
+		orderedCombos = append(orderedCombos, returnOsuFile.Colours.Combos[index])
 	}
 
+	if len(orderedCombos) != 0 {
+		returnOsuFile.Colours.Combos = orderedCombos
+	}
+
+	if !approachRateSet {
+		returnOsuFile.Difficulty.ApproachRate = returnOsuFile.Difficulty.OverallDifficulty
+	}
+
+	returnOsuFile.Md5Hash = hex.EncodeToString(hash.Sum(nil))
+
+	//Everything derived from sliders needs their paths, they get computed once here and broken ones get stopped
+	var budget *pathBudget
+
+	if maxCost := options.sliderPathCost(); maxCost > 0 {
+		budget = &pathBudget{remaining: maxCost}
+	}
+
+	paths := make([]SliderPath, len(returnOsuFile.HitObjects.List))
+
+	for j := range returnOsuFile.HitObjects.List {
+		hitObject := &returnOsuFile.HitObjects.List[j]
+
+		if hitObject.Type != HitObjectTypeSlider {
+			continue
+		}
+
+		paths[j] = newSliderPath(hitObject.CurveType, append([]Vec2{hitObject.Position}, hitObject.SliderPoints...), hitObject.SliderLength, budget)
+
+		if budget != nil && budget.remaining < 0 {
+			return OsuFile{}, fmt.Errorf("%w: slider paths cost more than %d to compute", ErrLimitExceeded, options.sliderPathCost())
+		}
+	}
+
+	//Slider end times need every timing point, which can come after the sliders, so they only get checked now
+	returnOsuFile.TimingPoints.Reindex()
+
+	diagnostics.section = sectionNames[SectionHitObjects]
+	diagnostics.currentLine = ""
+
+	hitObjects := returnOsuFile.HitObjects.List[:0]
+	keptPaths := paths[:0]
+
+	for j, hitObject := range returnOsuFile.HitObjects.List {
+		if hitObject.Type == HitObjectTypeSlider {
+			endTime := returnOsuFile.sliderTimingWithPath(&hitObject, paths[j]).EndTime
+
+			if !(endTime >= math.MinInt32 && endTime <= math.MaxInt32) {
+				diagnostics.error(hitObjectLines[j], "HitObjects Slider: Slider length", formatDouble(hitObject.SliderLength), -1, fmt.Errorf("%w: slider ends out of range", ErrIncorrectFormatting))
+				returnOsuFile.HitObjects.CountSlider--
+
+				if sourceMap != nil {
+					delete(sourceMap.hitObjectLines, hitObjectLines[j])
+				}
+
+				continue
+			}
+		}
+
+		if sourceMap != nil {
+			sourceMap.hitObjectLines[hitObjectLines[j]] = len(hitObjects)
+		}
+
+		hitObjects = append(hitObjects, hitObject)
+		keptPaths = append(keptPaths, paths[j])
+	}
+
+	returnOsuFile.HitObjects.List = hitObjects
+
+	if limitErr := checkLimits(); limitErr != nil {
+		return OsuFile{}, limitErr
+	}
+
+	returnOsuFile.computeDerivedValues(keptPaths)
+
+	return returnOsuFile, nil
+}
+
+// Converts a time to an int32 the way the game stores end times, times out of its range end up at its ends
+func timeToInt32(time float64) int32 {
+	if math.IsNaN(time) {
+		return 0
+	}
+
+	return int32(math.Max(math.MinInt32, math.Min(math.MaxInt32, time)))
+}
+
+// Everything worked out from the parsed sections: timing point lookups, slider end times,
+// stacking, combos, length and bpm. Paths of the sliders get computed if they're nil
+func (osuFile *OsuFile) computeDerivedValues(paths []SliderPath) {
+	//Built here so lookups on a parsed file never have to write to it
+	osuFile.TimingPoints.Reindex()
+
+	if paths == nil {
+		paths = osuFile.sliderPaths()
+	}
+
+	//Sliders only have an end time once all the timing points are known
+	for j := range osuFile.HitObjects.List {
+		hitObject := &osuFile.HitObjects.List[j]
+
+		if hitObject.Type == HitObjectTypeSlider {
+			hitObject.EndTime = timeToInt32(osuFile.sliderTimingWithPath(hitObject, paths[j]).EndTime)
+		}
+	}
+
+	osuFile.applyStackingWithPaths(paths)
+	osuFile.ApplyCombos()
+
 	//Commonly used computed things (length, drain length, bpm)
-	if len(returnOsuFile.TimingPoints.TimingPoints) != 0 {
-		returnOsuFile.FirstBpm = 60000.0 / returnOsuFile.TimingPoints.TimingPoints[0].BeatLength
+	if len(osuFile.TimingPoints.TimingPoints) != 0 {
+		osuFile.FirstBpm = 60000.0 / osuFile.TimingPoints.TimingPoints[0].BeatLength
 	}
 
-	if len(returnOsuFile.HitObjects.List) != 0 {
-		hitObjectCount := len(returnOsuFile.HitObjects.List)
-		totalLength := int64((returnOsuFile.HitObjects.List[hitObjectCount-1].Time - returnOsuFile.HitObjects.List[0].Time) / 1000)
+	if len(osuFile.HitObjects.List) != 0 {
+		hitObjectCount := len(osuFile.HitObjects.List)
+		totalLength := int64((osuFile.HitObjects.List[hitObjectCount-1].Time - osuFile.HitObjects.List[0].Time) / 1000)
 
 		breakTime := int32(0)
 
-		for _, event := range returnOsuFile.Events.Events {
+		for _, event := range osuFile.Events.Events {
 			if event.EventType == EventTypeBreak {
 				breakTime += event.BreakTimeEnd - event.BreakTimeBegin
 			}
@@ -770,9 +1153,7 @@ func ParseText(osuText string) (OsuFile, error) {
 
 		breakTime /= 1000
 
-		returnOsuFile.DrainLength = totalLength - int64(breakTime)
-		returnOsuFile.Length = totalLength
+		osuFile.DrainLength = totalLength - int64(breakTime)
+		osuFile.Length = totalLength
 	}
-
-	return returnOsuFile, nil
 }
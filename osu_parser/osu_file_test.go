@@ -14,9 +14,35 @@ func TestOsuParser(t *testing.T) {
 		t.Fail()
 	}
 
-	if len(parsedOsuFile.ParserWarnings) != 0 {
-		for _, warning := range parsedOsuFile.ParserWarnings {
-			fmt.Printf("%s", warning)
+	if len(parsedOsuFile.Diagnostics) != 0 {
+		for _, diagnostic := range parsedOsuFile.Diagnostics {
+			fmt.Printf("%s", diagnostic)
 		}
 	}
 }
+
+func TestOsuParserColours(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[Colours]\nCombo2 : 255,128,255\nCombo1 : 128,0,255\nSliderBorder : 10,20,30\nCombo3 : 255,0\n")
+
+	colours := parsedOsuFile.Colours
+
+	if len(colours.Combos) != 2 {
+		t.Fatalf("expected 2 combo colours, got %d", len(colours.Combos))
+	}
+
+	if colours.Combos[0] != (osu_parser.Color{R: 128, G: 0, B: 255}) || colours.Combos[1] != (osu_parser.Color{R: 255, G: 128, B: 255}) {
+		t.Fail()
+	}
+
+	if colours.SliderBorder == nil || *colours.SliderBorder != (osu_parser.Color{R: 10, G: 20, B: 30}) {
+		t.Fail()
+	}
+
+	if colours.SliderTrackOverride != nil {
+		t.Fail()
+	}
+
+	if len(parsedOsuFile.Diagnostics) != 1 {
+		t.Fail()
+	}
+}
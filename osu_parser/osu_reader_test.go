@@ -0,0 +1,47 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestParseReaderMatchesParseText(t *testing.T) {
+	data, _ := os.ReadFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	fromText, _ := osu_parser.ParseText(string(data))
+	fromReader, err := osu_parser.ParseReader(iotest.OneByteReader(strings.NewReader(string(data))))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fromText, fromReader) {
+		t.Fatal("ParseReader result differs from ParseText")
+	}
+
+	if fromReader.Md5Hash != "0dfcc1b4a695fac58bfc15782ad65fde" {
+		t.Fail()
+	}
+}
+
+func TestParseReaderError(t *testing.T) {
+	readErr := errors.New("connection reset")
+
+	_, err := osu_parser.ParseReader(iotest.TimeoutReader(strings.NewReader("osu file format v14\n[General]\nMode: 0\n")))
+
+	if err == nil {
+		t.Fatal("expected read error")
+	}
+
+	_, err = osu_parser.ParseReader(iotest.ErrReader(readErr))
+
+	if !errors.Is(err, readErr) {
+		t.Fatalf("expected %s, got %v", readErr, err)
+	}
+}
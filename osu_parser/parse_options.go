@@ -0,0 +1,135 @@
+package osu_parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+const (
+	//Many times what the longest ranked maps cost, a single broken 1000 point bezier costs millions
+	DefaultMaxSliderPathCost = 50000000
+	//Ten times the length slider paths get cut off at
+	DefaultMaxSliderLength = 10 * maxSliderLength
+)
+
+// Options for parsing untrusted beatmaps, the zero value parses everything only with the default slider limits
+type ParseOptions struct {
+	//Parsing stops with the context's error once it's done
+	Context context.Context
+
+	//Turns the first diagnostic into an error
+	Strict bool
+
+	//Sections which get skipped entirely
+	SkipSections []Section
+
+	//Stops at the first section after [General], [Editor], [Metadata] and [Difficulty].
+	//The rest of the input is still read so Md5Hash stays correct
+	HeaderOnly bool
+
+	//Limits, 0 means no limit. Going over a limit stops parsing with ErrLimitExceeded
+	MaxLineLength         int
+	MaxHitObjects         int
+	MaxSliderPoints       int
+	MaxStoryboardObjects  int
+	MaxStoryboardCommands int
+
+	//Limits the work of approximating every slider's path, which gets done for slider end times
+	//and stacking. Bezier curves cost the square of their point count every time they get subdivided,
+	//other curves about as much as the points they get approximated with.
+	//Regular sliders cost a few hundred each, a single broken 1000 point bezier costs millions.
+	//0 means DefaultMaxSliderPathCost, negative values turn the limit off
+	MaxSliderPathCost int
+
+	//Longest a slider's length may be in osu!pixels.
+	//0 means DefaultMaxSliderLength, negative values turn the limit off
+	MaxSliderLength float64
+}
+
+func ParseWithOptions(reader io.Reader, options ParseOptions) (OsuFile, error) {
+	return parseReader(reader, options, nil)
+}
+
+// Only reads [General], [Editor], [Metadata] and [Difficulty], for when the rest of the map isn't needed
+func ParseHeader(reader io.Reader) (OsuFile, error) {
+	return parseReader(reader, ParseOptions{HeaderOnly: true}, nil)
+}
+
+func ParseHeaderFile(filename string) (OsuFile, error) {
+	file, err := os.Open(filename)
+
+	if err != nil {
+		return OsuFile{}, err
+	}
+
+	defer file.Close()
+
+	return ParseHeader(file)
+}
+
+// Checks what .osu and .osb files have in common: cancellation, strict mode and the storyboard limits
+func (options *ParseOptions) checkStoryboardLimits(diagnostics []Diagnostic, storyboard *storyboardParser) error {
+	if options.Context != nil && options.Context.Err() != nil {
+		return options.Context.Err()
+	}
+
+	if options.Strict && len(diagnostics) != 0 {
+		return diagnostics[0]
+	}
+
+	if options.MaxStoryboardObjects > 0 && len(storyboard.storyboard.Objects) > options.MaxStoryboardObjects {
+		return fmt.Errorf("%w: more than %d storyboard objects", ErrLimitExceeded, options.MaxStoryboardObjects)
+	}
+
+	if options.MaxStoryboardCommands > 0 && storyboard.commandCount > options.MaxStoryboardCommands {
+		return fmt.Errorf("%w: more than %d storyboard commands", ErrLimitExceeded, options.MaxStoryboardCommands)
+	}
+
+	return nil
+}
+
+func (options *ParseOptions) sliderPathCost() int {
+	if options.MaxSliderPathCost == 0 {
+		return DefaultMaxSliderPathCost
+	}
+
+	return options.MaxSliderPathCost
+}
+
+func (options *ParseOptions) sliderLength() float64 {
+	if options.MaxSliderLength == 0 {
+		return DefaultMaxSliderLength
+	}
+
+	return options.MaxSliderLength
+}
+
+func (options *ParseOptions) stopsAt(section Section) bool {
+	if !options.HeaderOnly {
+		return false
+	}
+
+	switch section {
+	case SectionGeneral, SectionEditor, SectionMetadata, SectionDifficulty:
+		return false
+	}
+
+	return true
+}
+
+func (options *ParseOptions) skipsSection(section Section) bool {
+	for _, skipped := range options.SkipSections {
+		if skipped == section {
+			return true
+		}
+	}
+
+	return false
+}
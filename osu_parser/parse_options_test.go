@@ -0,0 +1,189 @@
+package osu_parser_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+const parseOptionsCase = "../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu"
+
+func TestParseOptionsStrict(t *testing.T) {
+	_, err := osu_parser.ParseWithOptions(strings.NewReader("osu file format v14\n\n[General]\nAudioLeadIn: abc\n"), osu_parser.ParseOptions{
+		Strict: true,
+	})
+
+	diagnostic := osu_parser.Diagnostic{}
+
+	if !errors.As(err, &diagnostic) || !errors.Is(err, osu_parser.ErrInvalidNumber) || diagnostic.Key != "AudioLeadIn" {
+		t.Fatalf("expected diagnostic error, got %v", err)
+	}
+}
+
+func TestParseOptionsSkipSections(t *testing.T) {
+	file, _ := os.Open(parseOptionsCase)
+	defer file.Close()
+
+	parsedOsuFile, err := osu_parser.ParseWithOptions(file, osu_parser.ParseOptions{
+		SkipSections: []osu_parser.Section{osu_parser.SectionHitObjects, osu_parser.SectionTimingPoints},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsedOsuFile.HitObjects.List) != 0 || len(parsedOsuFile.TimingPoints.TimingPoints) != 0 || parsedOsuFile.Metadata.Creator != "Furball" {
+		t.Fail()
+	}
+
+	if parsedOsuFile.Md5Hash != "0dfcc1b4a695fac58bfc15782ad65fde" {
+		t.Fail()
+	}
+}
+
+func TestParseOptionsLimits(t *testing.T) {
+	limits := []osu_parser.ParseOptions{
+		{MaxLineLength: 40},
+		{MaxHitObjects: 10},
+		{MaxSliderPoints: 1},
+	}
+
+	for _, options := range limits {
+		file, _ := os.Open(parseOptionsCase)
+
+		_, err := osu_parser.ParseWithOptions(file, options)
+
+		file.Close()
+
+		if !errors.Is(err, osu_parser.ErrLimitExceeded) {
+			t.Errorf("%+v: expected ErrLimitExceeded, got %v", options, err)
+		}
+	}
+
+	_, err := osu_parser.ParseWithOptions(strings.NewReader(storyboardTestText), osu_parser.ParseOptions{
+		MaxStoryboardCommands: 5,
+	})
+
+	if !errors.Is(err, osu_parser.ErrLimitExceeded) {
+		t.Errorf("expected ErrLimitExceeded for storyboard commands, got %v", err)
+	}
+
+	_, err = osu_parser.ParseWithOptions(strings.NewReader(storyboardTestText), osu_parser.ParseOptions{
+		MaxStoryboardObjects: 1,
+	})
+
+	if !errors.Is(err, osu_parser.ErrLimitExceeded) {
+		t.Errorf("expected ErrLimitExceeded for storyboard objects, got %v", err)
+	}
+
+	file, _ := os.Open(parseOptionsCase)
+	defer file.Close()
+
+	_, err = osu_parser.ParseWithOptions(file, osu_parser.ParseOptions{
+		MaxLineLength:        100,
+		MaxHitObjects:        1000,
+		MaxSliderPoints:      10,
+		MaxStoryboardObjects: 10,
+		MaxSliderPathCost:    100000,
+	})
+
+	if err != nil {
+		t.Fatalf("limits that aren't exceeded caused %v", err)
+	}
+}
+
+// Sliders within the point limit can still take long to compute, the path cost limit stops those
+func TestParseOptionsSliderPathCost(t *testing.T) {
+	points := []string{}
+
+	for i := 0; i < 1000; i++ {
+		points = append(points, fmt.Sprintf("%d:%d", i*37%512, i*91%384))
+	}
+
+	osuText := "osu file format v14\n\n[HitObjects]\n"
+
+	for i := 0; i < 100; i++ {
+		osuText += fmt.Sprintf("0,0,%d,2,0,B|%s,1,100\n", i*1000, strings.Join(points, "|"))
+	}
+
+	_, err := osu_parser.ParseWithOptions(strings.NewReader(osuText), osu_parser.ParseOptions{
+		MaxSliderPoints:   1000,
+		MaxSliderPathCost: 10000000,
+	})
+
+	if !errors.Is(err, osu_parser.ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded, got %v", err)
+	}
+}
+
+// Plain parsing has limits too, which only ever stop broken maps
+func TestParseOptionsDefaultSliderLimits(t *testing.T) {
+	points := []string{}
+
+	for i := 0; i < 1000; i++ {
+		points = append(points, fmt.Sprintf("%d:%d", i*37%512, i*91%384))
+	}
+
+	brokenBeziers := "osu file format v14\n\n[HitObjects]\n"
+
+	for i := 0; i < 100; i++ {
+		brokenBeziers += fmt.Sprintf("0,0,%d,2,0,B|%s,1,100\n", i*1000, strings.Join(points, "|"))
+	}
+
+	if _, err := osu_parser.ParseText(brokenBeziers); !errors.Is(err, osu_parser.ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded for path cost, got %v", err)
+	}
+
+	longSlider := fmt.Sprintf("osu file format v14\n\n[HitObjects]\n0,0,0,2,0,L|100:0,1,%.0f\n", osu_parser.DefaultMaxSliderLength+1)
+
+	if _, err := osu_parser.ParseText(longSlider); !errors.Is(err, osu_parser.ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded for slider length, got %v", err)
+	}
+
+	parsedOsuFile, err := osu_parser.ParseWithOptions(strings.NewReader(longSlider), osu_parser.ParseOptions{MaxSliderLength: -1})
+
+	if err != nil || len(parsedOsuFile.HitObjects.List) != 1 {
+		t.Fatalf("turning the limit off didn't parse the slider: %v", err)
+	}
+}
+
+func TestParseOptionsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	file, _ := os.Open(parseOptionsCase)
+	defer file.Close()
+
+	_, err := osu_parser.ParseWithOptions(file, osu_parser.ParseOptions{
+		Context: ctx,
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	parsedOsuFile, err := osu_parser.ParseHeaderFile(parseOptionsCase)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsedOsuFile.Metadata.Creator != "Furball" || parsedOsuFile.Metadata.Version != "Insane" || parsedOsuFile.Difficulty.SliderMultiplier == 0 {
+		t.Fatalf("header not parsed: %+v", parsedOsuFile.Metadata)
+	}
+
+	if len(parsedOsuFile.HitObjects.List) != 0 || len(parsedOsuFile.TimingPoints.TimingPoints) != 0 || len(parsedOsuFile.Events.Events) != 0 {
+		t.Fatal("sections after the header were parsed")
+	}
+
+	if parsedOsuFile.Md5Hash != "0dfcc1b4a695fac58bfc15782ad65fde" {
+		t.Fatalf("wrong hash %s", parsedOsuFile.Md5Hash)
+	}
+}
@@ -0,0 +1,493 @@
+package osu_parser
+
+import (
+	"math"
+)
+
+const (
+	osuPerformanceMultiplier   = 1.14
+	taikoPerformanceMultiplier = 1.13
+	maniaPerformanceMultiplier = 8.0
+)
+
+// Judgements of a score as the client counts them.
+// In osu!catch Count100 are droplets, Count50 tiny droplets and CountKatu missed tiny droplets.
+// In osu!mania CountGeki are MAX judgements and CountKatu 200s
+type ScoreStatistics struct {
+	Count300  int
+	Count100  int
+	Count50   int
+	CountMiss int
+	CountGeki int
+	CountKatu int
+
+	MaxCombo int
+	Mods     Mods
+}
+
+type OsuPerformanceAttributes struct {
+	Total float64
+
+	Aim        float64
+	Speed      float64
+	Accuracy   float64
+	Flashlight float64
+	//Misses plus an estimate of how many slider breaks there were
+	EffectiveMissCount float64
+}
+
+type TaikoPerformanceAttributes struct {
+	Total float64
+
+	Difficulty         float64
+	Accuracy           float64
+	EffectiveMissCount float64
+}
+
+type CatchPerformanceAttributes struct {
+	Total float64
+}
+
+type ManiaPerformanceAttributes struct {
+	Total float64
+
+	Difficulty float64
+}
+
+// Accuracy of the score between 0 and 1, the way the client shows it for mode
+func (score ScoreStatistics) Accuracy(mode Playmode) float64 {
+	var hits, total float64
+
+	switch mode {
+	case PlaymodeOsu:
+		hits = float64(score.Count300*6 + score.Count100*2 + score.Count50)
+		total = float64(score.Count300+score.Count100+score.Count50+score.CountMiss) * 6
+	case PlaymodeTaiko:
+		hits = float64(score.Count300*2 + score.Count100)
+		total = float64(score.Count300+score.Count100+score.CountMiss) * 2
+	case PlaymodeCatch:
+		hits = float64(score.Count300 + score.Count100 + score.Count50)
+		total = float64(score.Count300 + score.Count100 + score.Count50 + score.CountKatu + score.CountMiss)
+	case PlaymodeMania:
+		hits = float64((score.CountGeki+score.Count300)*6 + score.CountKatu*4 + score.Count100*2 + score.Count50)
+		total = float64(score.CountGeki+score.Count300+score.CountKatu+score.Count100+score.Count50+score.CountMiss) * 6
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return math.Max(0, math.Min(1, hits/total))
+}
+
+// Turns a star rating into a base pp value
+func performanceCurve(rating float64) float64 {
+	return math.Pow(5*math.Max(1, rating/0.0675)-4, 3) / 100000
+}
+
+// Performance points of an osu!standard score
+func (attributes OsuDifficultyAttributes) Performance(score ScoreStatistics) OsuPerformanceAttributes {
+	performance := OsuPerformanceAttributes{}
+
+	totalHits := float64(score.Count300 + score.Count100 + score.Count50 + score.CountMiss)
+
+	if totalHits == 0 {
+		return performance
+	}
+
+	performance.EffectiveMissCount = attributes.effectiveMissCount(score)
+
+	multiplier := osuPerformanceMultiplier
+
+	if score.Mods.Has(ModsNoFail) {
+		multiplier *= math.Max(0.9, 1-0.02*performance.EffectiveMissCount)
+	}
+
+	if score.Mods.Has(ModsSpunOut) {
+		multiplier *= 1 - math.Pow(float64(attributes.SpinnerCount)/totalHits, 0.85)
+	}
+
+	//100s and 50s are almost as bad as misses with relax, more so on higher OD
+	if score.Mods.Has(ModsRelax) {
+		okMultiplier := 1.0
+		mehMultiplier := 1.0
+
+		if attributes.OverallDifficulty > 0 {
+			okMultiplier = math.Max(0, 1-math.Pow(attributes.OverallDifficulty/13.33, 1.8))
+			mehMultiplier = math.Max(0, 1-math.Pow(attributes.OverallDifficulty/13.33, 5))
+		}
+
+		performance.EffectiveMissCount = math.Min(performance.EffectiveMissCount+float64(score.Count100)*okMultiplier+float64(score.Count50)*mehMultiplier, totalHits)
+	}
+
+	accuracy := score.Accuracy(PlaymodeOsu)
+
+	performance.Aim = attributes.aimPerformance(score, accuracy, performance.EffectiveMissCount)
+	performance.Speed = attributes.speedPerformance(score, accuracy, performance.EffectiveMissCount)
+	performance.Accuracy = attributes.accuracyPerformance(score)
+	performance.Flashlight = attributes.flashlightPerformance(score, accuracy, performance.EffectiveMissCount)
+
+	performance.Total = norm(1.1, performance.Aim, performance.Speed, performance.Accuracy, performance.Flashlight) * multiplier
+
+	return performance
+}
+
+// Scores with a lower combo than the map's probably broke some sliders, which don't show up as misses
+func (attributes OsuDifficultyAttributes) effectiveMissCount(score ScoreStatistics) float64 {
+	comboBasedMissCount := 0.0
+
+	if attributes.SliderCount > 0 {
+		fullComboThreshold := float64(attributes.MaxCombo) - 0.1*float64(attributes.SliderCount)
+
+		if float64(score.MaxCombo) < fullComboThreshold {
+			comboBasedMissCount = fullComboThreshold / math.Max(1, float64(score.MaxCombo))
+		}
+	}
+
+	comboBasedMissCount = math.Min(comboBasedMissCount, float64(score.Count100+score.Count50+score.CountMiss))
+
+	return math.Max(float64(score.CountMiss), comboBasedMissCount)
+}
+
+func (attributes OsuDifficultyAttributes) comboScaling(score ScoreStatistics) float64 {
+	if attributes.MaxCombo <= 0 {
+		return 1
+	}
+
+	return math.Min(math.Pow(float64(score.MaxCombo), 0.8)/math.Pow(float64(attributes.MaxCombo), 0.8), 1)
+}
+
+func osuLengthBonus(totalHits float64) float64 {
+	bonus := 0.95 + 0.4*math.Min(1, totalHits/2000)
+
+	if totalHits > 2000 {
+		bonus += math.Log10(totalHits/2000) * 0.5
+	}
+
+	return bonus
+}
+
+func (attributes OsuDifficultyAttributes) aimPerformance(score ScoreStatistics, accuracy float64, effectiveMissCount float64) float64 {
+	totalHits := float64(score.Count300 + score.Count100 + score.Count50 + score.CountMiss)
+	lengthBonus := osuLengthBonus(totalHits)
+
+	aim := performanceCurve(attributes.AimDifficulty) * lengthBonus
+
+	//Any miss takes away at least 3%
+	if effectiveMissCount > 0 {
+		aim *= 0.97 * math.Pow(1-math.Pow(effectiveMissCount/totalHits, 0.775), effectiveMissCount)
+	}
+
+	aim *= attributes.comboScaling(score)
+
+	approachRateFactor := 0.0
+
+	if attributes.ApproachRate > 10.33 {
+		approachRateFactor = 0.3 * (attributes.ApproachRate - 10.33)
+	} else if attributes.ApproachRate < 8 {
+		approachRateFactor = 0.05 * (8 - attributes.ApproachRate)
+	}
+
+	if score.Mods.Has(ModsRelax) {
+		approachRateFactor = 0
+	}
+
+	aim *= 1 + approachRateFactor*lengthBonus
+
+	//Hidden is worth more the lower the approach rate is
+	if score.Mods.Has(ModsHidden) {
+		aim *= 1 + 0.04*(12-attributes.ApproachRate)
+	}
+
+	//Some of the sliders are assumed to be difficult, and the score might've dropped their ends
+	estimateDifficultSliders := float64(attributes.SliderCount) * 0.15
+
+	if attributes.SliderCount > 0 {
+		estimateSliderEndsDropped := math.Max(0, math.Min(estimateDifficultSliders, math.Min(float64(score.Count100+score.Count50+score.CountMiss), float64(attributes.MaxCombo-score.MaxCombo))))
+		sliderNerfFactor := (1-attributes.SliderFactor)*math.Pow(1-estimateSliderEndsDropped/estimateDifficultSliders, 3) + attributes.SliderFactor
+
+		aim *= sliderNerfFactor
+	}
+
+	aim *= accuracy
+	aim *= 0.98 + math.Pow(attributes.OverallDifficulty, 2)/2500
+
+	return aim
+}
+
+func (attributes OsuDifficultyAttributes) speedPerformance(score ScoreStatistics, accuracy float64, effectiveMissCount float64) float64 {
+	if score.Mods.Has(ModsRelax) {
+		return 0
+	}
+
+	totalHits := float64(score.Count300 + score.Count100 + score.Count50 + score.CountMiss)
+	lengthBonus := osuLengthBonus(totalHits)
+
+	speed := performanceCurve(attributes.SpeedDifficulty) * lengthBonus
+
+	if effectiveMissCount > 0 {
+		speed *= 0.97 * math.Pow(1-math.Pow(effectiveMissCount/totalHits, 0.775), math.Pow(effectiveMissCount, 0.875))
+	}
+
+	speed *= attributes.comboScaling(score)
+
+	if attributes.ApproachRate > 10.33 {
+		speed *= 1 + 0.3*(attributes.ApproachRate-10.33)*lengthBonus
+	}
+
+	if score.Mods.Has(ModsHidden) {
+		speed *= 1 + 0.04*(12-attributes.ApproachRate)
+	}
+
+	//Accuracy on the notes relevant to speed, assuming the worst judgements landed on them
+	relevantTotalDiff := totalHits - attributes.SpeedNoteCount
+	relevantCount300 := math.Max(0, float64(score.Count300)-relevantTotalDiff)
+	relevantCount100 := math.Max(0, float64(score.Count100)-math.Max(0, relevantTotalDiff-float64(score.Count300)))
+	relevantCount50 := math.Max(0, float64(score.Count50)-math.Max(0, relevantTotalDiff-float64(score.Count300+score.Count100)))
+	relevantAccuracy := 0.0
+
+	if attributes.SpeedNoteCount != 0 {
+		relevantAccuracy = (relevantCount300*6 + relevantCount100*2 + relevantCount50) / (attributes.SpeedNoteCount * 6)
+	}
+
+	speed *= (0.95 + math.Pow(attributes.OverallDifficulty, 2)/750) * math.Pow((accuracy+relevantAccuracy)/2, (14.5-math.Max(attributes.OverallDifficulty, 8))/2)
+
+	//50s past a small amount count as doubletapping
+	if float64(score.Count50) >= totalHits/500 {
+		speed *= math.Pow(0.99, float64(score.Count50)-totalHits/500)
+	}
+
+	return speed
+}
+
+func (attributes OsuDifficultyAttributes) accuracyPerformance(score ScoreStatistics) float64 {
+	if score.Mods.Has(ModsRelax) {
+		return 0
+	}
+
+	totalHits := score.Count300 + score.Count100 + score.Count50 + score.CountMiss
+
+	//Only circles have a hit window, except for slider heads in ScoreV2
+	objectsWithAccuracy := attributes.HitCircleCount
+
+	if score.Mods.Has(ModsScoreV2) {
+		objectsWithAccuracy += attributes.SliderCount
+	}
+
+	betterAccuracyPercentage := 0.0
+
+	if objectsWithAccuracy > 0 {
+		betterAccuracyPercentage = float64((score.Count300-(totalHits-objectsWithAccuracy))*6+score.Count100*2+score.Count50) / float64(objectsWithAccuracy*6)
+	}
+
+	betterAccuracyPercentage = math.Max(0, betterAccuracyPercentage)
+
+	accuracy := math.Pow(1.52163, attributes.OverallDifficulty) * math.Pow(betterAccuracyPercentage, 24) * 2.83
+
+	//Keeping accuracy up is harder on longer maps
+	accuracy *= math.Min(1.15, math.Pow(float64(objectsWithAccuracy)/1000, 0.3))
+
+	if score.Mods.Has(ModsHidden) {
+		accuracy *= 1.08
+	}
+
+	if score.Mods.Has(ModsFlashlight) {
+		accuracy *= 1.02
+	}
+
+	return accuracy
+}
+
+func (attributes OsuDifficultyAttributes) flashlightPerformance(score ScoreStatistics, accuracy float64, effectiveMissCount float64) float64 {
+	if !score.Mods.Has(ModsFlashlight) {
+		return 0
+	}
+
+	totalHits := float64(score.Count300 + score.Count100 + score.Count50 + score.CountMiss)
+
+	flashlight := math.Pow(attributes.FlashlightDifficulty, 2) * 25
+
+	if effectiveMissCount > 0 {
+		flashlight *= 0.97 * math.Pow(1-math.Pow(effectiveMissCount/totalHits, 0.775), math.Pow(effectiveMissCount, 0.875))
+	}
+
+	flashlight *= attributes.comboScaling(score)
+
+	//Short maps spend more of their time with a small flashlight
+	lengthFactor := 0.7 + 0.1*math.Min(1, totalHits/200)
+
+	if totalHits > 200 {
+		lengthFactor += 0.2 * math.Min(1, (totalHits-200)/200)
+	}
+
+	flashlight *= lengthFactor
+	flashlight *= 0.5 + accuracy/2
+	flashlight *= 0.98 + math.Pow(attributes.OverallDifficulty, 2)/2500
+
+	return flashlight
+}
+
+// Performance points of an osu!taiko score
+func (attributes TaikoDifficultyAttributes) Performance(score ScoreStatistics) TaikoPerformanceAttributes {
+	performance := TaikoPerformanceAttributes{}
+
+	totalHits := float64(score.Count300 + score.Count100 + score.Count50 + score.CountMiss)
+	successfulHits := float64(score.Count300 + score.Count100 + score.Count50)
+
+	if totalHits == 0 {
+		return performance
+	}
+
+	//Misses count more on maps with less than 1000 notes
+	if successfulHits > 0 {
+		performance.EffectiveMissCount = math.Max(1, 1000/successfulHits) * float64(score.CountMiss)
+	}
+
+	multiplier := taikoPerformanceMultiplier
+
+	if score.Mods.Has(ModsHidden) {
+		multiplier *= 1.075
+	}
+
+	if score.Mods.Has(ModsEasy) {
+		multiplier *= 0.975
+	}
+
+	accuracy := score.Accuracy(PlaymodeTaiko)
+	lengthBonus := 1 + 0.1*math.Min(1, totalHits/1500)
+
+	difficulty := math.Pow(5*math.Max(1, attributes.StarRating/0.115)-4, 2.25) / 1150
+	difficulty *= lengthBonus
+	difficulty *= math.Pow(0.986, performance.EffectiveMissCount)
+
+	if score.Mods.Has(ModsEasy) {
+		difficulty *= 0.985
+	}
+
+	if score.Mods.Has(ModsHidden) {
+		difficulty *= 1.025
+	}
+
+	if score.Mods.Has(ModsHardRock) {
+		difficulty *= 1.05
+	}
+
+	if score.Mods.Has(ModsFlashlight) {
+		difficulty *= 1.05 * lengthBonus
+	}
+
+	performance.Difficulty = difficulty * math.Pow(accuracy, 2)
+
+	if attributes.GreatHitWindow > 0 {
+		accuracyLengthBonus := math.Min(1.15, math.Pow(totalHits/1500, 0.3))
+
+		performance.Accuracy = math.Pow(60/attributes.GreatHitWindow, 1.1) * math.Pow(accuracy, 8) * math.Pow(attributes.StarRating, 0.4) * 27
+		performance.Accuracy *= accuracyLengthBonus
+
+		if score.Mods.Has(ModsHidden | ModsFlashlight) {
+			performance.Accuracy *= math.Max(1.05, 1.075*accuracyLengthBonus)
+		}
+	}
+
+	performance.Total = norm(1.1, performance.Difficulty, performance.Accuracy) * multiplier
+
+	return performance
+}
+
+// Performance points of an osu!catch score
+func (attributes CatchDifficultyAttributes) Performance(score ScoreStatistics) CatchPerformanceAttributes {
+	performance := CatchPerformanceAttributes{}
+
+	//Fruits, droplets and misses, tiny droplets don't give combo
+	comboHits := float64(score.Count300 + score.Count100 + score.CountMiss)
+
+	if comboHits == 0 {
+		return performance
+	}
+
+	total := math.Pow(5*math.Max(1, attributes.StarRating/0.0049)-4, 2) / 100000
+
+	lengthBonus := 0.95 + 0.3*math.Min(1, comboHits/2500)
+
+	if comboHits > 2500 {
+		lengthBonus += math.Log10(comboHits/2500) * 0.475
+	}
+
+	total *= lengthBonus
+	total *= math.Pow(0.97, float64(score.CountMiss))
+
+	if attributes.MaxCombo > 0 {
+		total *= math.Min(math.Pow(float64(score.MaxCombo), 0.8)/math.Pow(float64(attributes.MaxCombo), 0.8), 1)
+	}
+
+	approachRate := attributes.ApproachRate
+	approachRateFactor := 1.0
+
+	if approachRate > 9 {
+		approachRateFactor += 0.1 * (approachRate - 9)
+	}
+
+	if approachRate > 10 {
+		approachRateFactor += 0.1 * (approachRate - 10)
+	} else if approachRate < 8 {
+		approachRateFactor += 0.025 * (8 - approachRate)
+	}
+
+	total *= approachRateFactor
+
+	//Hidden gives almost nothing on the highest approach rates
+	if score.Mods.Has(ModsHidden) {
+		if approachRate <= 10 {
+			total *= 1.05 + 0.075*(10-approachRate)
+		} else {
+			total *= 1.01 + 0.04*(11-math.Min(11, approachRate))
+		}
+	}
+
+	if score.Mods.Has(ModsFlashlight) {
+		total *= 1.35 * lengthBonus
+	}
+
+	total *= math.Pow(score.Accuracy(PlaymodeCatch), 5.5)
+
+	if score.Mods.Has(ModsNoFail) {
+		total *= 0.9
+	}
+
+	performance.Total = total
+
+	return performance
+}
+
+// Performance points of an osu!mania score
+func (attributes ManiaDifficultyAttributes) Performance(score ScoreStatistics) ManiaPerformanceAttributes {
+	performance := ManiaPerformanceAttributes{}
+
+	totalHits := float64(score.CountGeki + score.Count300 + score.CountKatu + score.Count100 + score.Count50 + score.CountMiss)
+
+	if totalHits == 0 {
+		return performance
+	}
+
+	//Unlike the accuracy the client shows, MAX judgements are worth more than 300s
+	accuracy := float64(score.CountGeki*320+score.Count300*300+score.CountKatu*200+score.Count100*100+score.Count50*50) / (totalHits * 320)
+
+	multiplier := maniaPerformanceMultiplier
+
+	if score.Mods.Has(ModsNoFail) {
+		multiplier *= 0.75
+	}
+
+	if score.Mods.Has(ModsEasy) {
+		multiplier *= 0.5
+	}
+
+	//Every percent of accuracy above 80% is worth a twentieth of the pp
+	performance.Difficulty = math.Pow(math.Max(attributes.StarRating-0.15, 0.05), 2.2) *
+		math.Max(0, 5*accuracy-4) *
+		(1 + 0.1*math.Min(1, totalHits/1500))
+
+	performance.Total = performance.Difficulty * multiplier
+
+	return performance
+}
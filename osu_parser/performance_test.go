@@ -0,0 +1,125 @@
+package osu_parser_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+// Regression values of the 2022 formulas, they haven't been checked against scores from the client
+func TestOsuPerformance(t *testing.T) {
+	attributes := osu_parser.OsuDifficultyAttributes{
+		AimDifficulty:        2.8,
+		SpeedDifficulty:      2.6,
+		FlashlightDifficulty: 2.1,
+		SpeedNoteCount:       420.5,
+		SliderFactor:         0.97,
+		ApproachRate:         9.3,
+		OverallDifficulty:    8.7,
+		MaxCombo:             1200,
+		HitCircleCount:       500,
+		SliderCount:          300,
+		SpinnerCount:         4,
+	}
+
+	tests := []struct {
+		name  string
+		score osu_parser.ScoreStatistics
+		pp    float64
+	}{
+		{"SS", osu_parser.ScoreStatistics{Count300: 804, MaxCombo: 1200}, 269.7256},
+		{"HD with misses", osu_parser.ScoreStatistics{Count300: 780, Count100: 20, Count50: 2, CountMiss: 2, MaxCombo: 600, Mods: osu_parser.ModsHidden}, 143.9165},
+		{"HDFL", osu_parser.ScoreStatistics{Count300: 790, Count100: 12, Count50: 2, MaxCombo: 1100, Mods: osu_parser.ModsHidden | osu_parser.ModsFlashlight}, 327.7546},
+		{"NFSO", osu_parser.ScoreStatistics{Count300: 770, Count100: 30, Count50: 4, MaxCombo: 1150, Mods: osu_parser.ModsNoFail | osu_parser.ModsSpunOut}, 175.3097},
+		{"RX", osu_parser.ScoreStatistics{Count300: 760, Count100: 30, Count50: 14, MaxCombo: 1200, Mods: osu_parser.ModsRelax}, 10.9408},
+		{"ScoreV2", osu_parser.ScoreStatistics{Count300: 804, MaxCombo: 1200, Mods: osu_parser.ModsScoreV2}, 283.6591},
+		{"no hits", osu_parser.ScoreStatistics{}, 0},
+	}
+
+	for _, test := range tests {
+		if pp := attributes.Performance(test.score).Total; math.Abs(pp-test.pp) > 0.0001 {
+			t.Errorf("%s: expected %f pp, got %f", test.name, test.pp, pp)
+		}
+	}
+}
+
+func TestTaikoPerformance(t *testing.T) {
+	attributes := osu_parser.TaikoDifficultyAttributes{StarRating: 4.2, GreatHitWindow: 29}
+
+	tests := []struct {
+		name  string
+		score osu_parser.ScoreStatistics
+		pp    float64
+	}{
+		{"SS", osu_parser.ScoreStatistics{Count300: 1000}, 215.0203},
+		{"HDHR with misses", osu_parser.ScoreStatistics{Count300: 950, Count100: 40, CountMiss: 10, Mods: osu_parser.ModsHidden | osu_parser.ModsHardRock}, 192.9242},
+		{"HDFL", osu_parser.ScoreStatistics{Count300: 990, Count100: 10, Mods: osu_parser.ModsHidden | osu_parser.ModsFlashlight}, 249.0813},
+		{"EZ", osu_parser.ScoreStatistics{Count300: 400, Count100: 20, CountMiss: 5, Mods: osu_parser.ModsEasy}, 140.5676},
+	}
+
+	for _, test := range tests {
+		if pp := attributes.Performance(test.score).Total; math.Abs(pp-test.pp) > 0.0001 {
+			t.Errorf("%s: expected %f pp, got %f", test.name, test.pp, pp)
+		}
+	}
+}
+
+func TestCatchPerformance(t *testing.T) {
+	attributes := osu_parser.CatchDifficultyAttributes{StarRating: 5.1, ApproachRate: 9.5, MaxCombo: 1500}
+
+	tests := []struct {
+		name  string
+		score osu_parser.ScoreStatistics
+		pp    float64
+	}{
+		{"SS", osu_parser.ScoreStatistics{Count300: 1200, Count100: 300, Count50: 400, MaxCombo: 1500}, 320.8397},
+		{"HD with misses", osu_parser.ScoreStatistics{Count300: 1180, Count100: 290, Count50: 380, CountKatu: 20, CountMiss: 30, MaxCombo: 700, Mods: osu_parser.ModsHidden}, 65.6711},
+		{"NFFL", osu_parser.ScoreStatistics{Count300: 1200, Count100: 300, Count50: 400, MaxCombo: 1500, Mods: osu_parser.ModsNoFail | osu_parser.ModsFlashlight}, 440.4968},
+	}
+
+	for _, test := range tests {
+		if pp := attributes.Performance(test.score).Total; math.Abs(pp-test.pp) > 0.0001 {
+			t.Errorf("%s: expected %f pp, got %f", test.name, test.pp, pp)
+		}
+	}
+}
+
+func TestManiaPerformance(t *testing.T) {
+	attributes := osu_parser.ManiaDifficultyAttributes{StarRating: 4.6}
+
+	tests := []struct {
+		name  string
+		score osu_parser.ScoreStatistics
+		pp    float64
+	}{
+		{"all MAX", osu_parser.ScoreStatistics{CountGeki: 1500}, 234.8955},
+		//Worked out by hand, 95% accuracy from (200 * 320 + 800 * 300) / (1000 * 320)
+		{"95%", osu_parser.ScoreStatistics{CountGeki: 200, Count300: 800}, 8 * math.Pow(4.45, 2.2) * 0.75 * (1 + 0.1*1000.0/1500)},
+		{"mixed", osu_parser.ScoreStatistics{CountGeki: 900, Count300: 450, CountKatu: 60, Count100: 30, Count50: 10, CountMiss: 50}, 133.3521},
+		{"NFEZ", osu_parser.ScoreStatistics{CountGeki: 1000, Count300: 400, CountKatu: 60, Count100: 20, Count50: 10, CountMiss: 10, Mods: osu_parser.ModsNoFail | osu_parser.ModsEasy}, 64.6880},
+	}
+
+	for _, test := range tests {
+		if pp := attributes.Performance(test.score).Total; math.Abs(pp-test.pp) > 0.0001 {
+			t.Errorf("%s: expected %f pp, got %f", test.name, test.pp, pp)
+		}
+	}
+}
+
+func TestScoreAccuracy(t *testing.T) {
+	score := osu_parser.ScoreStatistics{Count300: 90, Count100: 6, Count50: 3, CountMiss: 1, CountGeki: 10, CountKatu: 5}
+
+	expected := map[osu_parser.Playmode]float64{
+		osu_parser.PlaymodeOsu:   (90*300 + 6*100 + 3*50) / 30000.0,
+		osu_parser.PlaymodeTaiko: (90 + 3) / 97.0,
+		osu_parser.PlaymodeCatch: 99 / 105.0,
+		osu_parser.PlaymodeMania: (100*300 + 5*200 + 6*100 + 3*50) / 34500.0,
+	}
+
+	for mode, want := range expected {
+		if accuracy := score.Accuracy(mode); math.Abs(accuracy-want) > 1e-9 {
+			t.Errorf("mode %d: expected accuracy %f, got %f", mode, want, accuracy)
+		}
+	}
+}
@@ -0,0 +1,450 @@
+package osu_parser
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	//Maximum distance between the approximated and the real curve
+	bezierTolerance = 0.25
+	//Stops subdividing broken sliders with absurd coordinates before it takes forever,
+	//curves on the playfield are flat enough long before this
+	bezierMaxDepth    = 10
+	catmullDetail     = 50
+	circularTolerance = 0.1
+	//Arcs which would need more points than this are drawn as a bezier instead
+	circularMaxPoints = 1000
+)
+
+func (vec Vec2) add(other Vec2) Vec2 {
+	return Vec2{X: vec.X + other.X, Y: vec.Y + other.Y}
+}
+
+func (vec Vec2) sub(other Vec2) Vec2 {
+	return Vec2{X: vec.X - other.X, Y: vec.Y - other.Y}
+}
+
+func (vec Vec2) scale(factor float64) Vec2 {
+	return Vec2{X: vec.X * factor, Y: vec.Y * factor}
+}
+
+func (vec Vec2) dot(other Vec2) float64 {
+	return vec.X*other.X + vec.Y*other.Y
+}
+
+func (vec Vec2) lengthSquared() float64 {
+	return vec.dot(vec)
+}
+
+func (vec Vec2) length() float64 {
+	return math.Sqrt(vec.lengthSquared())
+}
+
+func almostEquals(a float64, b float64) bool {
+	return math.Abs(a-b) <= 1e-3
+}
+
+// The path a slider ball follows, approximated as a line through Points
+type SliderPath struct {
+	Points []Vec2
+
+	//Distance along the path to every point in Points
+	cumulativeLength []float64
+}
+
+// Computes the path of a slider the same way the game does.
+// controlPoints includes the head of the slider, expectedLength is the pixel length
+// the path gets cut or extended to, 0 or less keeps the length of the curve itself
+func NewSliderPath(curveType CurveType, controlPoints []Vec2, expectedLength float64) SliderPath {
+	return newSliderPath(curveType, controlPoints, expectedLength, nil)
+}
+
+// Limits how much work approximating paths may take, a nil budget has no limit
+type pathBudget struct {
+	remaining int
+}
+
+// Takes cost out of the budget, false once it's used up
+func (budget *pathBudget) spend(cost int) bool {
+	if budget == nil {
+		return true
+	}
+
+	budget.remaining -= cost
+
+	return budget.remaining >= 0
+}
+
+// Once the budget is used up curves stop getting refined, the path is still complete but rougher
+func newSliderPath(curveType CurveType, controlPoints []Vec2, expectedLength float64, budget *pathBudget) SliderPath {
+	path := SliderPath{}
+
+	//Circular arcs need exactly three points, anything else including red anchors is drawn as a bezier like the game does
+	if curveType == CurveTypePerfect && len(controlPoints) != 3 {
+		curveType = CurveTypeBezier
+	}
+
+	for _, segment := range splitSegments(curveType, controlPoints) {
+		for _, point := range approximateSegment(curveType, segment, budget) {
+			if len(path.Points) == 0 || path.Points[len(path.Points)-1] != point {
+				path.Points = append(path.Points, point)
+			}
+		}
+	}
+
+	path.calculateLength(controlPoints, expectedLength)
+
+	return path
+}
+
+// The path of a slider, for anything other than a slider it's only the position of the object
+func (hitObject *HitObject) Path() SliderPath {
+	controlPoints := append([]Vec2{hitObject.Position}, hitObject.SliderPoints...)
+
+	return NewSliderPath(hitObject.CurveType, controlPoints, hitObject.SliderLength)
+}
+
+// Length written in the file, which conversions go by instead of the computed path.
+// Sliders without one use the length of their path
+func (hitObject *HitObject) declaredLength() float64 {
+	if hitObject.SliderLength > 0 {
+		return hitObject.SliderLength
+	}
+
+	return hitObject.Path().Length()
+}
+
+// Length of the path in osu!pixels
+func (path SliderPath) Length() float64 {
+	if len(path.cumulativeLength) == 0 {
+		return 0
+	}
+
+	return path.cumulativeLength[len(path.cumulativeLength)-1]
+}
+
+// Position along the path, progress goes from 0 at the head to 1 at the end
+func (path SliderPath) PositionAt(progress float64) Vec2 {
+	if len(path.Points) == 0 {
+		return Vec2{}
+	}
+
+	distance := math.Max(0, math.Min(1, progress)) * path.Length()
+	index := sort.SearchFloat64s(path.cumulativeLength, distance)
+
+	if index <= 0 {
+		return path.Points[0]
+	}
+
+	if index >= len(path.Points) {
+		return path.Points[len(path.Points)-1]
+	}
+
+	start := path.Points[index-1]
+	end := path.Points[index]
+
+	startDistance := path.cumulativeLength[index-1]
+	endDistance := path.cumulativeLength[index]
+
+	if almostEquals(startDistance, endDistance) {
+		return start
+	}
+
+	weight := (distance - startDistance) / (endDistance - startDistance)
+
+	return start.add(end.sub(start).scale(weight))
+}
+
+func (path SliderPath) EndPosition() Vec2 {
+	return path.PositionAt(1)
+}
+
+// Two identical control points in a row (red anchors) start a new segment
+func splitSegments(curveType CurveType, controlPoints []Vec2) [][]Vec2 {
+	segments := [][]Vec2{}
+	start := 0
+
+	for i := 1; i < len(controlPoints); i++ {
+		if controlPoints[i] != controlPoints[i-1] {
+			continue
+		}
+
+		//Catmull sliders only ever have one segment
+		if curveType == CurveTypeCatmull && i > 1 {
+			continue
+		}
+
+		//The last point can't start a new segment
+		if i == len(controlPoints)-1 {
+			continue
+		}
+
+		segments = append(segments, controlPoints[start:i])
+		start = i
+	}
+
+	return append(segments, controlPoints[start:])
+}
+
+func approximateSegment(curveType CurveType, points []Vec2, budget *pathBudget) []Vec2 {
+	switch curveType {
+	case CurveTypeLinear:
+		budget.spend(len(points))
+
+		return points
+	case CurveTypeCatmull:
+		budget.spend(len(points) * catmullDetail)
+
+		return approximateCatmull(points)
+	case CurveTypePerfect:
+		if len(points) == 3 {
+			if arc, ok := approximateCircularArc(points); ok {
+				budget.spend(len(arc))
+
+				return arc
+			}
+		}
+	}
+
+	return approximateBezier(points, budget)
+}
+
+func (path *SliderPath) calculateLength(controlPoints []Vec2, expectedLength float64) {
+	calculatedLength := 0.0
+	path.cumulativeLength = []float64{0}
+
+	for i := 1; i < len(path.Points); i++ {
+		calculatedLength += path.Points[i].sub(path.Points[i-1]).length()
+		path.cumulativeLength = append(path.cumulativeLength, calculatedLength)
+	}
+
+	if len(path.Points) == 0 || expectedLength <= 0 || calculatedLength == expectedLength {
+		return
+	}
+
+	lastControlPoints := len(controlPoints)
+
+	//Sliders ending in a red anchor don't get extended
+	if lastControlPoints >= 2 && controlPoints[lastControlPoints-1] == controlPoints[lastControlPoints-2] && expectedLength > calculatedLength {
+		return
+	}
+
+	//The last point gets moved to wherever the expected length ends
+	path.cumulativeLength = path.cumulativeLength[:len(path.cumulativeLength)-1]
+	endIndex := len(path.Points) - 1
+
+	if calculatedLength > expectedLength {
+		for len(path.cumulativeLength) > 0 && path.cumulativeLength[len(path.cumulativeLength)-1] >= expectedLength {
+			path.cumulativeLength = path.cumulativeLength[:len(path.cumulativeLength)-1]
+			endIndex--
+		}
+	}
+
+	if endIndex <= 0 {
+		path.Points = path.Points[:1]
+		path.cumulativeLength = []float64{0}
+
+		return
+	}
+
+	path.Points = path.Points[:endIndex+1]
+
+	direction := path.Points[endIndex].sub(path.Points[endIndex-1])
+
+	if segmentLength := direction.length(); segmentLength != 0 {
+		direction = direction.scale(1 / segmentLength)
+	}
+
+	remaining := expectedLength - path.cumulativeLength[len(path.cumulativeLength)-1]
+
+	path.Points[endIndex] = path.Points[endIndex-1].add(direction.scale(remaining))
+	path.cumulativeLength = append(path.cumulativeLength, expectedLength)
+}
+
+func approximateCatmull(points []Vec2) []Vec2 {
+	result := []Vec2{}
+
+	findPoint := func(v1, v2, v3, v4 Vec2, t float64) Vec2 {
+		t2 := t * t
+		t3 := t * t2
+
+		return Vec2{
+			X: 0.5 * (2*v2.X + (-v1.X+v3.X)*t + (2*v1.X-5*v2.X+4*v3.X-v4.X)*t2 + (-v1.X+3*v2.X-3*v3.X+v4.X)*t3),
+			Y: 0.5 * (2*v2.Y + (-v1.Y+v3.Y)*t + (2*v1.Y-5*v2.Y+4*v3.Y-v4.Y)*t2 + (-v1.Y+3*v2.Y-3*v3.Y+v4.Y)*t3),
+		}
+	}
+
+	for i := 0; i < len(points)-1; i++ {
+		v1 := points[i]
+
+		if i > 0 {
+			v1 = points[i-1]
+		}
+
+		v2 := points[i]
+		v3 := points[i+1]
+		v4 := v3.add(v3).sub(v2)
+
+		if i < len(points)-2 {
+			v4 = points[i+2]
+		}
+
+		for c := 0; c < catmullDetail; c++ {
+			result = append(result, findPoint(v1, v2, v3, v4, float64(c)/catmullDetail))
+			result = append(result, findPoint(v1, v2, v3, v4, float64(c+1)/catmullDetail))
+		}
+	}
+
+	if len(result) == 0 {
+		return points
+	}
+
+	return result
+}
+
+// Fits a circle through the three points, fails if they're on a line or too far apart
+func approximateCircularArc(points []Vec2) ([]Vec2, bool) {
+	a := points[0]
+	b := points[1]
+	c := points[2]
+
+	aSq := b.sub(c).lengthSquared()
+	bSq := a.sub(c).lengthSquared()
+	cSq := a.sub(b).lengthSquared()
+
+	if almostEquals(aSq, 0) || almostEquals(bSq, 0) || almostEquals(cSq, 0) {
+		return nil, false
+	}
+
+	s := aSq * (bSq + cSq - aSq)
+	t := bSq * (aSq + cSq - bSq)
+	u := cSq * (aSq + bSq - cSq)
+
+	sum := s + t + u
+
+	if almostEquals(sum, 0) {
+		return nil, false
+	}
+
+	centre := a.scale(s).add(b.scale(t)).add(c.scale(u)).scale(1 / sum)
+
+	startOffset := a.sub(centre)
+	endOffset := c.sub(centre)
+
+	radius := startOffset.length()
+
+	thetaStart := math.Atan2(startOffset.Y, startOffset.X)
+	thetaEnd := math.Atan2(endOffset.Y, endOffset.X)
+
+	for thetaEnd < thetaStart {
+		thetaEnd += 2 * math.Pi
+	}
+
+	direction := 1.0
+	thetaRange := thetaEnd - thetaStart
+
+	//Goes the other way around if b is on the other side of the line from a to c
+	orthogonal := Vec2{X: c.Y - a.Y, Y: -(c.X - a.X)}
+
+	if orthogonal.dot(b.sub(a)) < 0 {
+		direction = -direction
+		thetaRange = 2*math.Pi - thetaRange
+	}
+
+	pointCount := 2
+
+	if 2*radius > circularTolerance {
+		pointCount = int(math.Max(2, math.Ceil(thetaRange/(2*math.Acos(1-circularTolerance/radius)))))
+	}
+
+	if pointCount >= circularMaxPoints {
+		return nil, false
+	}
+
+	result := make([]Vec2, pointCount)
+
+	for i := range result {
+		theta := thetaStart + direction*float64(i)/float64(pointCount-1)*thetaRange
+
+		result[i] = centre.add(Vec2{X: math.Cos(theta), Y: math.Sin(theta)}.scale(radius))
+	}
+
+	return result, true
+}
+
+// Every subdivision costs the square of the number of points
+func approximateBezier(points []Vec2, budget *pathBudget) []Vec2 {
+	if len(points) == 0 {
+		return points
+	}
+
+	result := []Vec2{}
+
+	var flatten func(curve []Vec2, depth int)
+
+	flatten = func(curve []Vec2, depth int) {
+		if depth >= bezierMaxDepth || !budget.spend(len(curve)*len(curve)) || bezierFlatEnough(curve) {
+			result = append(result, bezierApproximate(curve)...)
+
+			return
+		}
+
+		left, right := bezierSubdivide(curve)
+
+		flatten(left, depth+1)
+		flatten(right, depth+1)
+	}
+
+	flatten(points, 0)
+
+	return append(result, points[len(points)-1])
+}
+
+func bezierFlatEnough(curve []Vec2) bool {
+	for i := 1; i < len(curve)-1; i++ {
+		if curve[i-1].sub(curve[i].scale(2)).add(curve[i+1]).lengthSquared() > bezierTolerance*bezierTolerance*4 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Splits the curve in half with de Casteljau's algorithm
+func bezierSubdivide(curve []Vec2) ([]Vec2, []Vec2) {
+	count := len(curve)
+
+	midpoints := append([]Vec2{}, curve...)
+	left := make([]Vec2, count)
+	right := make([]Vec2, count)
+
+	for i := 0; i < count; i++ {
+		left[i] = midpoints[0]
+		right[count-i-1] = midpoints[count-i-1]
+
+		for j := 0; j < count-i-1; j++ {
+			midpoints[j] = midpoints[j].add(midpoints[j+1]).scale(0.5)
+		}
+	}
+
+	return left, right
+}
+
+// Points along a curve that's flat enough to be drawn as lines, without its last point
+func bezierApproximate(curve []Vec2) []Vec2 {
+	count := len(curve)
+
+	left, right := bezierSubdivide(curve)
+	joined := append(left, right[1:]...)
+
+	result := []Vec2{curve[0]}
+
+	for i := 1; i < count-1; i++ {
+		index := 2 * i
+
+		result = append(result, joined[index-1].add(joined[index].scale(2)).add(joined[index+1]).scale(0.25))
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+package osu_parser_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func closeTo(a osu_parser.Vec2, b osu_parser.Vec2) bool {
+	return math.Abs(a.X-b.X) < 0.01 && math.Abs(a.Y-b.Y) < 0.01
+}
+
+func TestSliderPathLinear(t *testing.T) {
+	path := osu_parser.NewSliderPath(osu_parser.CurveTypeLinear, []osu_parser.Vec2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}}, 150)
+
+	if path.Length() != 150 {
+		t.Fatalf("expected length 150, got %f", path.Length())
+	}
+
+	if !closeTo(path.PositionAt(0.5), osu_parser.Vec2{X: 75, Y: 0}) || !closeTo(path.EndPosition(), osu_parser.Vec2{X: 100, Y: 50}) {
+		t.Fatalf("wrong positions: %v %v", path.PositionAt(0.5), path.EndPosition())
+	}
+
+	//Extended past the last point in the direction of the last segment
+	extended := osu_parser.NewSliderPath(osu_parser.CurveTypeLinear, []osu_parser.Vec2{{X: 0, Y: 0}, {X: 100, Y: 0}}, 200)
+
+	if !closeTo(extended.EndPosition(), osu_parser.Vec2{X: 200, Y: 0}) {
+		t.Fatalf("path wasn't extended: %v", extended.EndPosition())
+	}
+}
+
+func TestSliderPathPerfect(t *testing.T) {
+	//Half a circle with a radius of 50
+	path := osu_parser.NewSliderPath(osu_parser.CurveTypePerfect, []osu_parser.Vec2{{X: 0, Y: 0}, {X: 50, Y: 50}, {X: 100, Y: 0}}, 0)
+
+	if math.Abs(path.Length()-50*math.Pi) > 0.5 {
+		t.Fatalf("expected length of half a circle, got %f", path.Length())
+	}
+
+	if !closeTo(path.PositionAt(0.5), osu_parser.Vec2{X: 50, Y: 50}) || !closeTo(path.EndPosition(), osu_parser.Vec2{X: 100, Y: 0}) {
+		t.Fatalf("wrong positions: %v %v", path.PositionAt(0.5), path.EndPosition())
+	}
+
+	//Points on a line can't make a circle, they become a bezier
+	collinear := osu_parser.NewSliderPath(osu_parser.CurveTypePerfect, []osu_parser.Vec2{{X: 0, Y: 0}, {X: 50, Y: 0}, {X: 100, Y: 0}}, 0)
+
+	if math.Abs(collinear.Length()-100) > 0.01 || !closeTo(collinear.EndPosition(), osu_parser.Vec2{X: 100, Y: 0}) {
+		t.Fatalf("collinear perfect curve incorrect: %f %v", collinear.Length(), collinear.EndPosition())
+	}
+
+	//More than three points, or a red anchor, turn the whole slider into a bezier instead of arcs
+	points := []osu_parser.Vec2{{X: 0, Y: 0}, {X: 50, Y: 50}, {X: 100, Y: 0}, {X: 100, Y: 0}, {X: 150, Y: -50}, {X: 200, Y: 0}}
+	anchored := osu_parser.NewSliderPath(osu_parser.CurveTypePerfect, points, 0)
+	bezier := osu_parser.NewSliderPath(osu_parser.CurveTypeBezier, points, 0)
+
+	if math.Abs(anchored.Length()-bezier.Length()) > 0.001 || !closeTo(anchored.PositionAt(0.25), bezier.PositionAt(0.25)) {
+		t.Fatalf("perfect curve with a red anchor isn't a bezier: %f %f", anchored.Length(), bezier.Length())
+	}
+
+	//Arcs would be half circles, the bezier is flatter
+	if closeTo(anchored.PositionAt(0.25), osu_parser.Vec2{X: 50, Y: 50}) {
+		t.Fatalf("perfect curve with a red anchor was split into arcs")
+	}
+}
+
+func TestSliderPathBezier(t *testing.T) {
+	path := osu_parser.NewSliderPath(osu_parser.CurveTypeBezier, []osu_parser.Vec2{{X: 0, Y: 0}, {X: 50, Y: 100}, {X: 100, Y: 0}}, 100)
+
+	if path.Length() != 100 {
+		t.Fatalf("expected length 100, got %f", path.Length())
+	}
+
+	//The curve is symmetric, so cutting it at its full length ends it back on the ground
+	full := osu_parser.NewSliderPath(osu_parser.CurveTypeBezier, []osu_parser.Vec2{{X: 0, Y: 0}, {X: 50, Y: 100}, {X: 100, Y: 0}}, 0)
+
+	if !closeTo(full.EndPosition(), osu_parser.Vec2{X: 100, Y: 0}) || !closeTo(full.PositionAt(0.5), osu_parser.Vec2{X: 50, Y: 50}) {
+		t.Fatalf("wrong positions: %v %v", full.PositionAt(0.5), full.EndPosition())
+	}
+
+	//A red anchor makes two straight segments
+	anchored := osu_parser.NewSliderPath(osu_parser.CurveTypeBezier, []osu_parser.Vec2{{X: 0, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 0}, {X: 100, Y: 100}}, 0)
+
+	if math.Abs(anchored.Length()-200) > 0.01 || !closeTo(anchored.PositionAt(0.5), osu_parser.Vec2{X: 100, Y: 0}) {
+		t.Fatalf("red anchor not respected: %f %v", anchored.Length(), anchored.PositionAt(0.5))
+	}
+}
+
+func TestSliderPathCatmull(t *testing.T) {
+	path := osu_parser.NewSliderPath(osu_parser.CurveTypeCatmull, []osu_parser.Vec2{{X: 0, Y: 0}, {X: 50, Y: 50}, {X: 100, Y: 0}}, 0)
+
+	if !closeTo(path.PositionAt(0), osu_parser.Vec2{X: 0, Y: 0}) || !closeTo(path.EndPosition(), osu_parser.Vec2{X: 100, Y: 0}) {
+		t.Fatalf("wrong positions: %v %v", path.PositionAt(0), path.EndPosition())
+	}
+}
+
+func TestHitObjectPath(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[HitObjects]\n0,0,1000,2,0,L|100:0,1,50\n")
+
+	path := parsedOsuFile.HitObjects.List[0].Path()
+
+	if path.Length() != 50 || !closeTo(path.EndPosition(), osu_parser.Vec2{X: 50, Y: 0}) {
+		t.Fatalf("wrong slider path: %f %v", path.Length(), path.EndPosition())
+	}
+}
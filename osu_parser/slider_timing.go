@@ -0,0 +1,200 @@
+package osu_parser
+
+import (
+	"math"
+)
+
+const (
+	//Distance a slider travels in one beat at a slider multiplier of 1
+	baseScoringDistance = 100.0
+	//The game moves the last tick this many milliseconds before the end of the slider
+	legacyLastTickOffset = 36.0
+	maxSliderLength      = 100000.0
+	//Absurd tick rates would give millions of ticks, no slider gets more than this over all of its spans
+	maxSliderTicks = 32768
+
+	defaultSliderMultiplier = 1.4
+	defaultSliderTickRate   = 1.0
+)
+
+type SliderEventType int32
+
+const (
+	SliderEventHead           SliderEventType = 0
+	SliderEventTick           SliderEventType = 1
+	SliderEventRepeat         SliderEventType = 2
+	SliderEventLegacyLastTick SliderEventType = 3
+	SliderEventTail           SliderEventType = 4
+)
+
+// Something that happens while a slider is being held, the head, tail, every tick and repeat
+type SliderEvent struct {
+	Type     SliderEventType
+	Time     float64
+	Position Vec2
+
+	//Which pass over the path the event is on, counting from 0
+	SpanIndex int32
+	//How far along the path the event is, from 0 to 1
+	PathProgress float64
+}
+
+type SliderTiming struct {
+	//osu!pixels per millisecond
+	Velocity float64
+	//Time one pass over the path takes
+	SpanDuration float64
+	SpanCount    int32
+	StartTime    float64
+	EndTime      float64
+	TickDistance float64
+
+	Path SliderPath
+}
+
+// Works out how fast and how long a slider is, based on the timing point it starts on
+func (osuFile *OsuFile) SliderTiming(hitObject *HitObject) SliderTiming {
+	return osuFile.sliderTimingWithPath(hitObject, hitObject.Path())
+}
+
+// Paths of every slider in the map, the ones of other objects are left empty
+func (osuFile *OsuFile) sliderPaths() []SliderPath {
+	paths := make([]SliderPath, len(osuFile.HitObjects.List))
+
+	for i := range osuFile.HitObjects.List {
+		if osuFile.HitObjects.List[i].Type == HitObjectTypeSlider {
+			paths[i] = osuFile.HitObjects.List[i].Path()
+		}
+	}
+
+	return paths
+}
+
+// SliderTiming with a path that was already computed
+func (osuFile *OsuFile) sliderTimingWithPath(hitObject *HitObject, path SliderPath) SliderTiming {
+	sliderMultiplier := osuFile.Difficulty.SliderMultiplier
+	tickRate := osuFile.Difficulty.SliderTickRate
+
+	if !(sliderMultiplier > 0) {
+		sliderMultiplier = defaultSliderMultiplier
+	}
+
+	if !(tickRate > 0) {
+		tickRate = defaultSliderTickRate
+	}
+
+	sliderVelocity := osuFile.TimingPoints.SliderVelocityAt(hitObject.Time)
+	scoringDistance := baseScoringDistance * sliderMultiplier * sliderVelocity
+
+	timing := SliderTiming{
+		Velocity:     scoringDistance / osuFile.TimingPoints.BeatLengthAt(hitObject.Time),
+		TickDistance: scoringDistance / tickRate,
+		SpanCount:    hitObject.RepeatCount,
+		StartTime:    hitObject.Time,
+		Path:         path,
+	}
+
+	//Older versions kept the tick distance the same regardless of slider velocity
+	if osuFile.Version < 8 {
+		timing.TickDistance /= sliderVelocity
+	}
+
+	if timing.SpanCount < 1 {
+		timing.SpanCount = 1
+	}
+
+	timing.SpanDuration = timing.Path.Length() / timing.Velocity
+	timing.EndTime = timing.StartTime + float64(timing.SpanCount)*timing.SpanDuration
+
+	return timing
+}
+
+// Where the slider ends, which is back at the head after an even number of spans
+func (timing SliderTiming) EndPosition() Vec2 {
+	return timing.Path.PositionAt(float64(timing.SpanCount % 2))
+}
+
+// The head, ticks, repeats, legacy last tick and tail of the slider in order of time
+func (timing SliderTiming) NestedObjects() []SliderEvent {
+	length := math.Min(maxSliderLength, timing.Path.Length())
+	tickDistance := math.Max(0, math.Min(length, timing.TickDistance))
+
+	if tickDistance != 0 {
+		tickDistance = math.Max(tickDistance, length*float64(timing.SpanCount)/maxSliderTicks)
+	}
+	//Ticks too close to the end of a span get left out
+	minDistanceFromEnd := timing.Velocity * 10
+
+	events := []SliderEvent{}
+
+	addEvent := func(eventType SliderEventType, time float64, spanIndex int32, progress float64) {
+		events = append(events, SliderEvent{
+			Type:         eventType,
+			Time:         time,
+			Position:     timing.Path.PositionAt(progress),
+			SpanIndex:    spanIndex,
+			PathProgress: progress,
+		})
+	}
+
+	addEvent(SliderEventHead, timing.StartTime, 0, 0)
+
+	for span := int32(0); span < timing.SpanCount; span++ {
+		spanStartTime := timing.StartTime + float64(span)*timing.SpanDuration
+		reversed := span%2 == 1
+
+		ticks := []float64{}
+
+		if tickDistance != 0 {
+			for distance := tickDistance; distance <= length; distance += tickDistance {
+				if distance >= length-minDistanceFromEnd {
+					break
+				}
+
+				ticks = append(ticks, distance/length)
+			}
+		}
+
+		for j := range ticks {
+			progress := ticks[j]
+
+			//Going back over the path, the ticks further along come first
+			if reversed {
+				progress = ticks[len(ticks)-1-j]
+			}
+
+			timeProgress := progress
+
+			if reversed {
+				timeProgress = 1 - progress
+			}
+
+			addEvent(SliderEventTick, spanStartTime+timeProgress*timing.SpanDuration, span, progress)
+		}
+
+		if span < timing.SpanCount-1 {
+			addEvent(SliderEventRepeat, spanStartTime+timing.SpanDuration, span, float64((span+1)%2))
+		}
+	}
+
+	totalDuration := float64(timing.SpanCount) * timing.SpanDuration
+
+	finalSpanIndex := timing.SpanCount - 1
+	finalSpanStartTime := timing.StartTime + float64(finalSpanIndex)*timing.SpanDuration
+	finalSpanEndTime := math.Max(timing.StartTime+totalDuration/2, finalSpanStartTime+timing.SpanDuration-legacyLastTickOffset)
+
+	finalProgress := 0.0
+
+	if timing.SpanDuration != 0 {
+		finalProgress = (finalSpanEndTime - finalSpanStartTime) / timing.SpanDuration
+	}
+
+	if timing.SpanCount%2 == 0 {
+		finalProgress = 1 - finalProgress
+	}
+
+	addEvent(SliderEventLegacyLastTick, finalSpanEndTime, finalSpanIndex, finalProgress)
+	addEvent(SliderEventTail, timing.EndTime, finalSpanIndex, float64(timing.SpanCount%2))
+
+	return events
+}
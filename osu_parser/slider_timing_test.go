@@ -0,0 +1,119 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+const sliderTimingTestText = `osu file format v14
+
+[Difficulty]
+SliderMultiplier:1
+SliderTickRate:1
+
+[TimingPoints]
+0,500,4,2,0,100,1,0
+1000,-50,4,2,0,100,0,0
+
+[HitObjects]
+0,0,0,2,0,L|200:0,2,200
+0,0,1000,6,0,L|200:0,1,200
+`
+
+func TestSliderTiming(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(sliderTimingTestText)
+
+	first := parsedOsuFile.SliderTiming(&parsedOsuFile.HitObjects.List[0])
+
+	if first.Velocity != 0.2 || first.SpanDuration != 1000 || first.EndTime != 2000 || parsedOsuFile.HitObjects.List[0].EndTime != 2000 {
+		t.Fatalf("first slider timing incorrect: %+v", first)
+	}
+
+	//Twice the slider velocity from the green line
+	second := parsedOsuFile.SliderTiming(&parsedOsuFile.HitObjects.List[1])
+
+	if second.Velocity != 0.4 || second.SpanDuration != 500 || parsedOsuFile.HitObjects.List[1].EndTime != 1500 {
+		t.Fatalf("second slider timing incorrect: %+v", second)
+	}
+}
+
+func TestSliderNestedObjects(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(sliderTimingTestText)
+
+	events := parsedOsuFile.SliderTiming(&parsedOsuFile.HitObjects.List[0]).NestedObjects()
+
+	expected := []osu_parser.SliderEvent{
+		{Type: osu_parser.SliderEventHead, Time: 0, Position: osu_parser.Vec2{X: 0, Y: 0}, SpanIndex: 0, PathProgress: 0},
+		{Type: osu_parser.SliderEventTick, Time: 500, Position: osu_parser.Vec2{X: 100, Y: 0}, SpanIndex: 0, PathProgress: 0.5},
+		{Type: osu_parser.SliderEventRepeat, Time: 1000, Position: osu_parser.Vec2{X: 200, Y: 0}, SpanIndex: 0, PathProgress: 1},
+		{Type: osu_parser.SliderEventTick, Time: 1500, Position: osu_parser.Vec2{X: 100, Y: 0}, SpanIndex: 1, PathProgress: 0.5},
+		{Type: osu_parser.SliderEventLegacyLastTick, Time: 1964, Position: osu_parser.Vec2{X: 7.2, Y: 0}, SpanIndex: 1, PathProgress: 0.036},
+		{Type: osu_parser.SliderEventTail, Time: 2000, Position: osu_parser.Vec2{X: 0, Y: 0}, SpanIndex: 1, PathProgress: 0},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+
+	for i, event := range events {
+		want := expected[i]
+
+		if event.Type != want.Type || event.Time != want.Time || event.SpanIndex != want.SpanIndex || math.Abs(event.PathProgress-want.PathProgress) > 1e-9 || !closeTo(event.Position, want.Position) {
+			t.Fatalf("event %d incorrect: got %+v, expected %+v", i, event, want)
+		}
+	}
+}
+
+// A broken tick rate can't make a slider generate millions of ticks
+func TestSliderNestedObjectsTickLimit(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[Difficulty]\nSliderMultiplier:1\nSliderTickRate:1000000000\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n0,0,0,2,0,L|500:0,100,50000\n")
+
+	events := parsedOsuFile.SliderTiming(&parsedOsuFile.HitObjects.List[0]).NestedObjects()
+
+	if len(events) > 32768+200 {
+		t.Fatalf("expected at most 32768 ticks, got %d events", len(events))
+	}
+
+	ticks := 0
+
+	for _, event := range events {
+		if event.Type == osu_parser.SliderEventTick {
+			ticks++
+		}
+	}
+
+	if ticks == 0 {
+		t.Fatal("expected the slider to still have ticks")
+	}
+}
+
+func TestSliderTimingOutOfRange(t *testing.T) {
+	//Timing points after the objects, which only get used once the whole file was read
+	osuText := "osu file format v14\n\n[Difficulty]\nSliderMultiplier:0.001\n\n[HitObjects]\n0,0,0,1,0\n0,0,1000,2,0,L|100:0,1,999000000000\n0,0,2000,2,0,L|100:0,1,1000000\n0,0,5000,1,0\n\n[TimingPoints]\n0,60000,4,2,0,100,1,0\n"
+
+	parsedOsuFile, _ := osu_parser.ParseText(osuText)
+
+	if len(parsedOsuFile.Diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", parsedOsuFile.Diagnostics)
+	}
+
+	for i, line := range []int{8, 9} {
+		if diagnostic := parsedOsuFile.Diagnostics[i]; diagnostic.Line != line || !errors.Is(diagnostic, osu_parser.ErrIncorrectFormatting) {
+			t.Errorf("expected a diagnostic on line %d, got %+v", line, diagnostic)
+		}
+	}
+
+	if len(parsedOsuFile.HitObjects.List) != 2 || parsedOsuFile.HitObjects.CountSlider != 0 || parsedOsuFile.HitObjects.List[1].Time != 5000 {
+		t.Fatalf("expected only the circles, got %+v", parsedOsuFile.HitObjects)
+	}
+
+	//Skipped lines stay in the file like every other line that couldn't be read
+	losslessOsuFile, _ := osu_parser.ParseTextLossless(osuText)
+
+	if losslessOsuFile.Encode() != osuText {
+		t.Fatal("lossless encoding changed the file")
+	}
+}
@@ -0,0 +1,178 @@
+package osu_parser
+
+const (
+	//Objects closer than this get stacked
+	stackDistance = 3.0
+	//File format version which introduced the current stacking algorithm
+	stackingVersion = 6
+)
+
+// Time an object is visible for before it has to be hit
+func preemptTime(approachRate float64) float64 {
+	switch {
+	case approachRate < 5:
+		return 1200 + 600*(5-approachRate)/5
+	case approachRate > 5:
+		return 1200 - 750*(approachRate-5)/5
+	}
+
+	return 1200
+}
+
+// Approach rate which gives the preempt time, going past 10 for preempt times shorter than 450ms
+func approachRateFromPreempt(preempt float64) float64 {
+	if preempt > 1200 {
+		return (1800 - preempt) / 120
+	}
+
+	return (1200-preempt)/150 + 5
+}
+
+// Scale of hit circles compared to a circle size of 0
+func circleScale(circleSize float64) float64 {
+	return (1 - 0.7*(circleSize-5)/5) / 2
+}
+
+func distance(a Vec2, b Vec2) float64 {
+	return a.sub(b).length()
+}
+
+// Recalculates StackHeight and StackedPosition of every hit object.
+// Stacking only happens in osu!standard, in other modes everything stays where it is
+func (osuFile *OsuFile) ApplyStacking() {
+	osuFile.applyStackingWithPaths(osuFile.sliderPaths())
+}
+
+func (osuFile *OsuFile) applyStackingWithPaths(paths []SliderPath) {
+	hitObjects := osuFile.HitObjects.List
+
+	endTimes := make([]float64, len(hitObjects))
+	endPositions := make([]Vec2, len(hitObjects))
+	pathEndPositions := make([]Vec2, len(hitObjects))
+
+	for i := range hitObjects {
+		hitObject := &hitObjects[i]
+
+		hitObject.StackHeight = 0
+		endTimes[i] = hitObject.Time
+		endPositions[i] = hitObject.Position
+		pathEndPositions[i] = hitObject.Position
+
+		switch hitObject.Type {
+		case HitObjectTypeSlider:
+			timing := osuFile.sliderTimingWithPath(hitObject, paths[i])
+
+			endTimes[i] = timing.EndTime
+			endPositions[i] = timing.EndPosition()
+			pathEndPositions[i] = timing.Path.EndPosition()
+		case HitObjectTypeSpinner:
+			endTimes[i] = float64(hitObject.EndTime)
+		}
+	}
+
+	if osuFile.General.Mode == PlaymodeOsu {
+		stackThreshold := preemptTime(osuFile.Difficulty.ApproachRate) * osuFile.General.StackLeniency
+
+		if osuFile.Version >= stackingVersion {
+			applyStacking(hitObjects, endTimes, endPositions, stackThreshold)
+		} else {
+			applyStackingOld(hitObjects, endTimes, pathEndPositions, stackThreshold)
+		}
+	}
+
+	stackOffset := circleScale(osuFile.Difficulty.CircleSize) * -6.4
+
+	for i := range hitObjects {
+		hitObject := &hitObjects[i]
+
+		hitObject.StackedPosition = hitObject.Position.add(Vec2{X: 1, Y: 1}.scale(float64(hitObject.StackHeight) * stackOffset))
+	}
+}
+
+// Goes backwards through the map, stacking every object onto the ones before it
+func applyStacking(hitObjects []HitObject, endTimes []float64, endPositions []Vec2, stackThreshold float64) {
+	for i := len(hitObjects) - 1; i > 0; i-- {
+		objectI := i
+
+		if hitObjects[objectI].StackHeight != 0 || hitObjects[objectI].Type == HitObjectTypeSpinner {
+			continue
+		}
+
+		if hitObjects[objectI].Type == HitObjectTypeSlider {
+			for n := i - 1; n >= 0; n-- {
+				if hitObjects[n].Type == HitObjectTypeSpinner {
+					continue
+				}
+
+				if hitObjects[objectI].Time-hitObjects[n].Time > stackThreshold {
+					break
+				}
+
+				if distance(endPositions[n], hitObjects[objectI].Position) < stackDistance {
+					hitObjects[n].StackHeight = hitObjects[objectI].StackHeight + 1
+					objectI = n
+				}
+			}
+
+			continue
+		}
+
+		for n := i - 1; n >= 0; n-- {
+			if hitObjects[n].Type == HitObjectTypeSpinner {
+				continue
+			}
+
+			if hitObjects[objectI].Time-endTimes[n] > stackThreshold {
+				break
+			}
+
+			//Objects stacked under the end of a slider move down and right instead of up and left
+			if hitObjects[n].Type == HitObjectTypeSlider && distance(endPositions[n], hitObjects[objectI].Position) < stackDistance {
+				offset := hitObjects[objectI].StackHeight - hitObjects[n].StackHeight + 1
+
+				for j := n + 1; j <= i; j++ {
+					if distance(endPositions[n], hitObjects[j].Position) < stackDistance {
+						hitObjects[j].StackHeight -= offset
+					}
+				}
+
+				break
+			}
+
+			if distance(hitObjects[n].Position, hitObjects[objectI].Position) < stackDistance {
+				hitObjects[n].StackHeight = hitObjects[objectI].StackHeight + 1
+				objectI = n
+			}
+		}
+	}
+}
+
+// Stacking used by maps before file format version 6, which goes forwards through the map.
+// Sliders stack with the end of their path, even when repeats make them end back at the head
+func applyStackingOld(hitObjects []HitObject, endTimes []float64, pathEndPositions []Vec2, stackThreshold float64) {
+	for i := range hitObjects {
+		current := &hitObjects[i]
+
+		if current.StackHeight != 0 && current.Type != HitObjectTypeSlider {
+			continue
+		}
+
+		startTime := endTimes[i]
+		sliderStack := int32(0)
+
+		for j := i + 1; j < len(hitObjects); j++ {
+			if hitObjects[j].Time-stackThreshold > startTime {
+				break
+			}
+
+			if distance(hitObjects[j].Position, current.Position) < stackDistance {
+				current.StackHeight++
+				startTime = endTimes[j]
+			} else if distance(hitObjects[j].Position, pathEndPositions[i]) < stackDistance {
+				sliderStack++
+				hitObjects[j].StackHeight -= sliderStack
+				startTime = endTimes[j]
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package osu_parser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+const stackingTestText = `osu file format v%d
+
+[General]
+StackLeniency: 0.7
+Mode: %d
+
+[Difficulty]
+CircleSize:4
+ApproachRate:5
+SliderMultiplier:1
+
+[TimingPoints]
+0,500,4,2,0,100,1,0
+
+[HitObjects]
+100,100,0,1,0,0:0:0:0:
+100,100,100,1,0,0:0:0:0:
+100,100,200,1,0,0:0:0:0:
+0,0,5000,2,0,L|100:0,1,100
+100,0,5600,1,0,0:0:0:0:
+`
+
+func stackHeights(parsedOsuFile osu_parser.OsuFile) []int32 {
+	heights := []int32{}
+
+	for _, hitObject := range parsedOsuFile.HitObjects.List {
+		heights = append(heights, hitObject.StackHeight)
+	}
+
+	return heights
+}
+
+func TestStacking(t *testing.T) {
+	for _, version := range []int{14, 5} {
+		parsedOsuFile, _ := osu_parser.ParseText(fmt.Sprintf(stackingTestText, version, osu_parser.PlaymodeOsu))
+
+		heights := stackHeights(parsedOsuFile)
+
+		if fmt.Sprint(heights) != "[2 1 0 0 -1]" {
+			t.Fatalf("v%d: wrong stack heights %v", version, heights)
+		}
+
+		first := parsedOsuFile.HitObjects.List[0].StackedPosition
+
+		if !closeTo(first, osu_parser.Vec2{X: 100 - 2*3.648, Y: 100 - 2*3.648}) {
+			t.Fatalf("v%d: wrong stacked position %v", version, first)
+		}
+
+		//Stacked under the end of the slider, so moved down and right
+		last := parsedOsuFile.HitObjects.List[4].StackedPosition
+
+		if !closeTo(last, osu_parser.Vec2{X: 100 + 3.648, Y: 3.648}) {
+			t.Fatalf("v%d: wrong stacked position %v", version, last)
+		}
+	}
+}
+
+func TestStackingOld(t *testing.T) {
+	//The circle at 2500 is still in range of the long slider before it,
+	//the circle at 6200 is on the end of a path which repeats back to its head
+	parsedOsuFile, _ := osu_parser.ParseText(`osu file format v5
+
+[General]
+StackLeniency: 0.7
+
+[Difficulty]
+ApproachRate:5
+SliderMultiplier:1
+
+[TimingPoints]
+0,500,4,2,0,100,1,0
+
+[HitObjects]
+300,300,0,1,0,0:0:0:0:
+300,300,100,2,0,L|400:300,4,100
+300,300,2500,1,0,0:0:0:0:
+0,0,5000,2,0,L|100:0,2,100
+100,0,6200,1,0,0:0:0:0:
+`)
+
+	heights := stackHeights(parsedOsuFile)
+
+	if fmt.Sprint(heights) != "[2 1 0 0 -1]" {
+		t.Fatalf("wrong stack heights %v", heights)
+	}
+}
+
+func TestStackingOtherModes(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(fmt.Sprintf(stackingTestText, 14, osu_parser.PlaymodeTaiko))
+
+	for _, hitObject := range parsedOsuFile.HitObjects.List {
+		if hitObject.StackHeight != 0 || hitObject.StackedPosition != hitObject.Position {
+			t.Fatalf("stacked outside of osu!standard: %+v", hitObject)
+		}
+	}
+}
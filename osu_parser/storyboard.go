@@ -0,0 +1,493 @@
+package osu_parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type storyboardParser struct {
+	storyboard *Storyboard
+
+	diagnostics *diagnosticCollector
+
+	//Variables defined in [Variables], in order of definition
+	variables []storyboardVariable
+	//Longest a line may get after substituting variables, 0 means maxSubstitutedLineLength
+	maxLineLength int
+
+	//Amount of commands parsed so far, including the ones inside of loops and triggers
+	commandCount int
+
+	//Index of the object commands currently get added to, -1 if none
+	objectIndex int
+	//Index of the loop/trigger in the current object nested commands get added to, -1 if none
+	compoundIndex int
+}
+
+// Limit on lines with variables substituted when ParseOptions doesn't limit line length.
+// Every variable can multiply the length of a line, a few short ones referencing each other would grow it exponentially
+const maxSubstitutedLineLength = 1 << 16
+
+type storyboardVariable struct {
+	name  string
+	value string
+}
+
+func newStoryboardParser(storyboard *Storyboard, diagnostics *diagnosticCollector) *storyboardParser {
+	return &storyboardParser{
+		storyboard:    storyboard,
+		diagnostics:   diagnostics,
+		objectIndex:   -1,
+		compoundIndex: -1,
+	}
+}
+
+var eventTypeNames = map[string]EventType{
+	"Background": EventTypeBackground,
+	"Video":      EventTypeVideo,
+	"Break":      EventTypeBreak,
+	"Colour":     EventTypeColor,
+	"Sprite":     EventTypeSprite,
+	"Sample":     EventTypeSample,
+	"Animation":  EventTypeAnimation,
+}
+
+var storyboardLayerNames = map[string]StoryboardLayer{
+	"Background": StoryboardLayerBackground,
+	"Fail":       StoryboardLayerFail,
+	"Pass":       StoryboardLayerPass,
+	"Foreground": StoryboardLayerForeground,
+	"Overlay":    StoryboardLayerOverlay,
+}
+
+var storyboardOriginNames = map[string]StoryboardOrigin{
+	"TopLeft":      OriginTopLeft,
+	"Centre":       OriginCentre,
+	"CentreLeft":   OriginCentreLeft,
+	"TopRight":     OriginTopRight,
+	"BottomCentre": OriginBottomCentre,
+	"TopCentre":    OriginTopCentre,
+	"Custom":       OriginCustom,
+	"CentreRight":  OriginCentreRight,
+	"BottomLeft":   OriginBottomLeft,
+	"BottomRight":  OriginBottomRight,
+}
+
+var commandTypeNames = map[string]CommandType{
+	"F":  CommandTypeFade,
+	"M":  CommandTypeMove,
+	"MX": CommandTypeMoveX,
+	"MY": CommandTypeMoveY,
+	"S":  CommandTypeScale,
+	"V":  CommandTypeVectorScale,
+	"R":  CommandTypeRotate,
+	"C":  CommandTypeColour,
+	"P":  CommandTypeParameter,
+	"L":  CommandTypeLoop,
+	"T":  CommandTypeTrigger,
+}
+
+// How many values a single step of a command takes
+var commandValueCounts = map[CommandType]int{
+	CommandTypeFade:        1,
+	CommandTypeMove:        2,
+	CommandTypeMoveX:       1,
+	CommandTypeMoveY:       1,
+	CommandTypeScale:       1,
+	CommandTypeVectorScale: 2,
+	CommandTypeRotate:      1,
+	CommandTypeColour:      3,
+}
+
+// Event types can either be written as their number or their name
+func parseEventType(value string) (EventType, bool) {
+	if eventType, ok := eventTypeNames[value]; ok {
+		return eventType, true
+	}
+
+	parsed, parseErr := strconv.ParseInt(value, 10, 32)
+
+	if parseErr != nil {
+		return 0, false
+	}
+
+	return EventType(parsed), true
+}
+
+// Storyboard commands are indented using either spaces or underscores,
+// one per nesting level
+func storyboardDepth(rawLine string) int {
+	depth := 0
+
+	for depth < len(rawLine) && (rawLine[depth] == ' ' || rawLine[depth] == '_') {
+		depth++
+	}
+
+	return depth
+}
+
+// Parses a $name=value line of the [Variables] section
+func (parser *storyboardParser) parseVariable(line int, content string, offset int) {
+	split := strings.SplitN(content, "=", 2)
+
+	if len(split) != 2 || !strings.HasPrefix(split[0], "$") {
+		parser.diagnostics.error(line, "[Variables]", content, offset, fmt.Errorf("%w of variable", ErrIncorrectFormatting))
+
+		return
+	}
+
+	parser.variables = append(parser.variables, storyboardVariable{
+		name:  split[0],
+		value: split[1],
+	})
+}
+
+func (parser *storyboardParser) lineLimit() int {
+	if parser.maxLineLength > 0 {
+		return parser.maxLineLength
+	}
+
+	return maxSubstitutedLineLength
+}
+
+// Substitutes variables the same way stable does, every variable once in order of definition.
+// Returns false if the line would get longer than the limit
+func (parser *storyboardParser) substituteVariables(rawLine string) (string, bool) {
+	for _, variable := range parser.variables {
+		count := strings.Count(rawLine, variable.name)
+
+		if count == 0 {
+			continue
+		}
+
+		//Checked before replacing, so the line never gets built
+		if len(rawLine)+count*(len(variable.value)-len(variable.name)) > parser.lineLimit() {
+			return rawLine, false
+		}
+
+		rawLine = strings.ReplaceAll(rawLine, variable.name, variable.value)
+	}
+
+	return rawLine, true
+}
+
+// Parses a line of the [Events] section, returns false if the line
+// isn't a storyboard line and should be handled as a regular event
+func (parser *storyboardParser) parseLine(line int, rawLine string) bool {
+	substituted := false
+
+	if len(parser.variables) != 0 {
+		original := rawLine
+		substitutedLine, ok := parser.substituteVariables(rawLine)
+
+		if !ok {
+			parser.diagnostics.error(line, "[Events]", original, 0, fmt.Errorf("%w: line longer than %d characters after substituting variables", ErrLimitExceeded, parser.lineLimit()))
+
+			//Commands after an object which got skipped don't belong to the one before it
+			if storyboardDepth(original) == 0 {
+				parser.objectIndex = -1
+				parser.compoundIndex = -1
+			}
+
+			return true
+		}
+
+		rawLine = substitutedLine
+		substituted = rawLine != original
+	}
+
+	depth := storyboardDepth(rawLine)
+	contentOffset := depth
+
+	//Values of a line with variables in it aren't where they are in the line as written
+	if substituted {
+		contentOffset = -1
+	}
+
+	content, contentOffset := trimOffset(rawLine[depth:], contentOffset, "\t\r ")
+
+	if depth > 0 {
+		parser.parseCommand(line, depth, content, contentOffset)
+
+		return true
+	}
+
+	split, offsets := splitOffsets(content, contentOffset, ",")
+	eventType, ok := parseEventType(split[0])
+
+	if !ok {
+		parser.objectIndex = -1
+		parser.compoundIndex = -1
+
+		return false
+	}
+
+	switch eventType {
+	case EventTypeSprite, EventTypeAnimation, EventTypeSample:
+		parser.parseObject(line, eventType, split, offsets)
+
+		return true
+	}
+
+	//Any other event ends the current storyboard object
+	parser.objectIndex = -1
+	parser.compoundIndex = -1
+
+	return false
+}
+
+func (parser *storyboardParser) parseLayer(line int, value string, offset int) StoryboardLayer {
+	if layer, ok := storyboardLayerNames[value]; ok {
+		return layer
+	}
+
+	layer := int32(0)
+
+	parser.diagnostics.parseInt(line, "Storyboard Layer", value, offset, &layer)
+
+	return StoryboardLayer(layer)
+}
+
+func (parser *storyboardParser) parseOrigin(line int, value string, offset int) StoryboardOrigin {
+	if origin, ok := storyboardOriginNames[value]; ok {
+		return origin
+	}
+
+	origin := int32(0)
+
+	parser.diagnostics.parseInt(line, "Storyboard Origin", value, offset, &origin)
+
+	return StoryboardOrigin(origin)
+}
+
+func (parser *storyboardParser) parseObject(line int, eventType EventType, split []string, offsets []int) {
+	parser.objectIndex = -1
+	parser.compoundIndex = -1
+
+	object := StoryboardObject{
+		Type: eventType,
+	}
+
+	switch eventType {
+	case EventTypeSample:
+		//Sample,time,layer,"filepath",volume
+		if len(split) < 4 {
+			parser.diagnostics.error(line, "[Events]", strings.Join(split, ","), offsets[0], fmt.Errorf("%w of storyboard sample", ErrIncorrectFormatting))
+
+			return
+		}
+
+		object.Volume = 100
+
+		parser.diagnostics.parseInt(line, "Storyboard Sample: Time", split[1], offsets[1], &object.Time)
+		object.Layer = parser.parseLayer(line, split[2], offsets[2])
+		object.FilePath = strings.Trim(split[3], "\" ")
+
+		if len(split) > 4 {
+			parser.diagnostics.parseInt(line, "Storyboard Sample: Volume", split[4], offsets[4], &object.Volume)
+		}
+	default:
+		//Sprite,layer,origin,"filepath",x,y
+		//Animation,layer,origin,"filepath",x,y,frameCount,frameDelay,looptype
+		if len(split) < 6 || (eventType == EventTypeAnimation && len(split) < 8) {
+			parser.diagnostics.error(line, "[Events]", strings.Join(split, ","), offsets[0], fmt.Errorf("%w of storyboard object", ErrIncorrectFormatting))
+
+			return
+		}
+
+		object.Layer = parser.parseLayer(line, split[1], offsets[1])
+		object.Origin = parser.parseOrigin(line, split[2], offsets[2])
+		object.FilePath = strings.Trim(split[3], "\" ")
+
+		parser.diagnostics.parseDouble(line, "Storyboard Object: Position", split[4], offsets[4], &object.Position.X)
+		parser.diagnostics.parseDouble(line, "Storyboard Object: Position", split[5], offsets[5], &object.Position.Y)
+
+		if eventType == EventTypeAnimation {
+			parser.diagnostics.parseInt(line, "Storyboard Animation: Frame count", split[6], offsets[6], &object.FrameCount)
+			parser.diagnostics.parseDouble(line, "Storyboard Animation: Frame delay", split[7], offsets[7], &object.FrameDelay)
+
+			if len(split) > 8 {
+				switch split[8] {
+				case "LoopOnce", "1":
+					object.LoopType = AnimationLoopOnce
+				default:
+					object.LoopType = AnimationLoopForever
+				}
+			}
+		}
+	}
+
+	parser.storyboard.Objects = append(parser.storyboard.Objects, object)
+	parser.objectIndex = len(parser.storyboard.Objects) - 1
+}
+
+func (parser *storyboardParser) parseCommand(line int, depth int, content string, contentOffset int) {
+	if parser.objectIndex == -1 {
+		parser.diagnostics.error(line, "[Events]", content, contentOffset, fmt.Errorf("%w: storyboard command without an object", ErrIncorrectFormatting))
+
+		return
+	}
+
+	object := &parser.storyboard.Objects[parser.objectIndex]
+
+	if depth > 1 && parser.compoundIndex == -1 {
+		parser.diagnostics.error(line, "[Events]", content, contentOffset, fmt.Errorf("%w: nested storyboard command outside of a loop or trigger", ErrIncorrectFormatting))
+
+		return
+	}
+
+	split, offsets := splitOffsets(content, contentOffset, ",")
+	commandType, ok := commandTypeNames[split[0]]
+
+	if !ok {
+		parser.diagnostics.error(line, "[Events]", split[0], offsets[0], fmt.Errorf("%w: storyboard command %s", ErrUnknownValue, split[0]))
+
+		return
+	}
+
+	commands := []StoryboardCommand{}
+
+	switch commandType {
+	case CommandTypeLoop:
+		//L,starttime,loopcount
+		if len(split) < 3 {
+			parser.diagnostics.error(line, "[Events]", content, contentOffset, fmt.Errorf("%w of loop command", ErrIncorrectFormatting))
+
+			return
+		}
+
+		loop := StoryboardCommand{
+			Type: CommandTypeLoop,
+		}
+
+		parser.diagnostics.parseInt(line, "Storyboard Loop: Start time", split[1], offsets[1], &loop.StartTime)
+		parser.diagnostics.parseInt(line, "Storyboard Loop: Loop count", split[2], offsets[2], &loop.LoopCount)
+
+		commands = append(commands, loop)
+	case CommandTypeTrigger:
+		//T,triggerType,starttime,endtime[,groupNumber]
+		if len(split) < 4 {
+			parser.diagnostics.error(line, "[Events]", content, contentOffset, fmt.Errorf("%w of trigger command", ErrIncorrectFormatting))
+
+			return
+		}
+
+		trigger := StoryboardCommand{
+			Type:        CommandTypeTrigger,
+			TriggerName: split[1],
+		}
+
+		parser.diagnostics.parseInt(line, "Storyboard Trigger: Start time", split[2], offsets[2], &trigger.StartTime)
+		parser.diagnostics.parseInt(line, "Storyboard Trigger: End time", split[3], offsets[3], &trigger.EndTime)
+
+		if len(split) > 4 {
+			parser.diagnostics.parseInt(line, "Storyboard Trigger: Group number", split[4], offsets[4], &trigger.TriggerGroup)
+		}
+
+		commands = append(commands, trigger)
+	default:
+		//type,easing,starttime,endtime,values...
+		if len(split) < 5 {
+			parser.diagnostics.error(line, "[Events]", content, contentOffset, fmt.Errorf("%w of storyboard command", ErrIncorrectFormatting))
+
+			return
+		}
+
+		easing := int32(0)
+		startTime := int32(0)
+
+		parser.diagnostics.parseInt(line, "Storyboard Command: Easing", split[1], offsets[1], &easing)
+		parser.diagnostics.parseInt(line, "Storyboard Command: Start time", split[2], offsets[2], &startTime)
+
+		//An empty end time means the command is instant
+		endTime := startTime
+
+		if len(split[3]) != 0 {
+			parser.diagnostics.parseInt(line, "Storyboard Command: End time", split[3], offsets[3], &endTime)
+		}
+
+		if commandType == CommandTypeParameter {
+			commands = append(commands, StoryboardCommand{
+				Type:      commandType,
+				Easing:    Easing(easing),
+				StartTime: startTime,
+				EndTime:   endTime,
+				Parameter: split[4],
+			})
+
+			break
+		}
+
+		valueCount := commandValueCounts[commandType]
+		valueStrings := split[4:]
+
+		if len(valueStrings) < valueCount {
+			parser.diagnostics.error(line, "[Events]", content, contentOffset, fmt.Errorf("%w: not enough values for storyboard command %s", ErrIncorrectFormatting, split[0]))
+
+			return
+		}
+
+		values := make([]float64, len(valueStrings))
+
+		for j, valueString := range valueStrings {
+			parser.diagnostics.parseDouble(line, "Storyboard Command: Values", valueString, offsets[4+j], &values[j])
+		}
+
+		steps := len(values) / valueCount
+
+		//A single set of values means the value doesn't change
+		if steps == 1 {
+			commands = append(commands, StoryboardCommand{
+				Type:        commandType,
+				Easing:      Easing(easing),
+				StartTime:   startTime,
+				EndTime:     endTime,
+				StartValues: values[0:valueCount],
+				EndValues:   values[0:valueCount],
+			})
+
+			break
+		}
+
+		//Any more sets of values continue the command with the same duration
+		duration := endTime - startTime
+
+		for step := 0; step < steps-1; step++ {
+			stepStart := startTime + int32(step)*duration
+
+			commands = append(commands, StoryboardCommand{
+				Type:        commandType,
+				Easing:      Easing(easing),
+				StartTime:   stepStart,
+				EndTime:     stepStart + duration,
+				StartValues: values[step*valueCount : (step+1)*valueCount],
+				EndValues:   values[(step+1)*valueCount : (step+2)*valueCount],
+			})
+		}
+	}
+
+	parser.commandCount += len(commands)
+
+	if depth > 1 {
+		compound := &object.Commands[parser.compoundIndex]
+
+		if commandType == CommandTypeLoop || commandType == CommandTypeTrigger {
+			parser.diagnostics.error(line, "[Events]", content, contentOffset, fmt.Errorf("%w: loops and triggers cannot be nested", ErrIncorrectFormatting))
+
+			return
+		}
+
+		compound.Commands = append(compound.Commands, commands...)
+
+		return
+	}
+
+	object.Commands = append(object.Commands, commands...)
+
+	if commandType == CommandTypeLoop || commandType == CommandTypeTrigger {
+		parser.compoundIndex = len(object.Commands) - 1
+	} else {
+		parser.compoundIndex = -1
+	}
+}
@@ -0,0 +1,213 @@
+package osu_parser_test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+const storyboardTestText = `osu file format v14
+
+[Events]
+//Background and Video events
+0,0,"bg.jpg",0,0
+Video,500,"video.avi"
+//Storyboard Layer 0 (Background)
+Sprite,Background,Centre,"sb/light.png",320,240
+ F,0,1000,2000,0,1
+ M,1,1000,,100,200
+_S,0,0,1000,0.5,1,0.5
+ L,3000,4
+  R,0,0,500,0,3.14
+ T,HitSoundClap,0,10000
+  C,0,0,100,255,255,255,0,0,0
+ P,0,0,,A
+//Storyboard Layer 3 (Foreground)
+Animation,Foreground,TopLeft,"sb/anim.png",0,0,4,50,LoopOnce
+ V,0,0,100,1,1,2,2
+//Storyboard Sound Samples
+Sample,2500,0,"sb/clap.wav",60
+`
+
+func TestStoryboardParser(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseText(storyboardTestText)
+
+	if len(parsedOsuFile.Diagnostics) != 0 {
+		t.Fatalf("unexpected warnings: %v", parsedOsuFile.Diagnostics)
+	}
+
+	if len(parsedOsuFile.Events.Events) != 2 || parsedOsuFile.Events.Events[1].EventType != osu_parser.EventTypeVideo {
+		t.Fatalf("expected background and video events, got %v", parsedOsuFile.Events.Events)
+	}
+
+	objects := parsedOsuFile.Events.Storyboard.Objects
+
+	if len(objects) != 3 {
+		t.Fatalf("expected 3 storyboard objects, got %d", len(objects))
+	}
+
+	sprite := objects[0]
+
+	if sprite.Type != osu_parser.EventTypeSprite || sprite.Layer != osu_parser.StoryboardLayerBackground || sprite.Origin != osu_parser.OriginCentre || sprite.FilePath != "sb/light.png" || sprite.Position != (osu_parser.Vec2{X: 320, Y: 240}) {
+		t.Fatalf("sprite parsed incorrectly: %+v", sprite)
+	}
+
+	//F, M, 2 steps of S, L, T, P
+	if len(sprite.Commands) != 7 {
+		t.Fatalf("expected 7 sprite commands, got %d", len(sprite.Commands))
+	}
+
+	move := sprite.Commands[1]
+
+	if move.Type != osu_parser.CommandTypeMove || move.Easing != osu_parser.EasingOut || move.EndTime != 1000 || move.StartValues[1] != 200 {
+		t.Fatalf("move command parsed incorrectly: %+v", move)
+	}
+
+	scaleSecondStep := sprite.Commands[3]
+
+	if scaleSecondStep.StartTime != 1000 || scaleSecondStep.EndTime != 2000 || scaleSecondStep.StartValues[0] != 1 || scaleSecondStep.EndValues[0] != 0.5 {
+		t.Fatalf("scale shorthand parsed incorrectly: %+v", scaleSecondStep)
+	}
+
+	loop := sprite.Commands[4]
+
+	if loop.Type != osu_parser.CommandTypeLoop || loop.LoopCount != 4 || len(loop.Commands) != 1 || loop.Commands[0].Type != osu_parser.CommandTypeRotate {
+		t.Fatalf("loop parsed incorrectly: %+v", loop)
+	}
+
+	trigger := sprite.Commands[5]
+
+	if trigger.Type != osu_parser.CommandTypeTrigger || trigger.TriggerName != "HitSoundClap" || len(trigger.Commands) != 1 || len(trigger.Commands[0].EndValues) != 3 {
+		t.Fatalf("trigger parsed incorrectly: %+v", trigger)
+	}
+
+	if sprite.Commands[6].Parameter != "A" {
+		t.Fail()
+	}
+
+	animation := objects[1]
+
+	if animation.Type != osu_parser.EventTypeAnimation || animation.FrameCount != 4 || animation.FrameDelay != 50 || animation.LoopType != osu_parser.AnimationLoopOnce || len(animation.Commands) != 1 {
+		t.Fatalf("animation parsed incorrectly: %+v", animation)
+	}
+
+	sample := objects[2]
+
+	if sample.Type != osu_parser.EventTypeSample || sample.Time != 2500 || sample.Volume != 60 || sample.FilePath != "sb/clap.wav" {
+		t.Fatalf("sample parsed incorrectly: %+v", sample)
+	}
+}
+
+const osbTestText = `[Variables]
+$bg=Background,Centre
+$fade=F,0,0,1000,1,0
+
+[Events]
+//Storyboard Layer 0 (Background)
+Sprite,$bg,"sb/shared.png",320,240
+ $fade
+//Storyboard Layer 3 (Foreground)
+Sprite,Foreground,Centre,"sb/shared_front.png",320,240
+`
+
+func TestStoryboardFileMerge(t *testing.T) {
+	osbFile, _ := osu_parser.ParseStoryboardText(osbTestText)
+
+	if len(osbFile.Diagnostics) != 0 {
+		t.Fatalf("unexpected warnings: %v", osbFile.Diagnostics)
+	}
+
+	shared := osbFile.Storyboard
+
+	if len(shared.Objects) != 2 || shared.Objects[0].Origin != osu_parser.OriginCentre || len(shared.Objects[0].Commands) != 1 {
+		t.Fatalf("variables not substituted correctly: %+v", shared.Objects)
+	}
+
+	parsedOsuFile, _ := osu_parser.ParseText(storyboardTestText)
+
+	merged := shared.Merge(parsedOsuFile.Events.Storyboard)
+
+	expectedPaths := []string{
+		"sb/shared.png",
+		"sb/light.png",
+		"sb/clap.wav",
+		"sb/shared_front.png",
+		"sb/anim.png",
+	}
+
+	if len(merged.Objects) != len(expectedPaths) {
+		t.Fatalf("expected %d merged objects, got %d", len(expectedPaths), len(merged.Objects))
+	}
+
+	for i, object := range merged.Objects {
+		if object.FilePath != expectedPaths[i] {
+			t.Errorf("merged object %d: expected %s, got %s", i, expectedPaths[i], object.FilePath)
+		}
+	}
+}
+
+func TestStoryboardVariablesSinglePass(t *testing.T) {
+	//Values aren't substituted again, so a variable referencing itself only doubles once
+	osbFile, _ := osu_parser.ParseStoryboardText("[Variables]\n$a=$a$a\n\n[Events]\nSprite,Background,Centre,\"$a.png\",0,0\n")
+
+	if len(osbFile.Storyboard.Objects) != 1 || osbFile.Storyboard.Objects[0].FilePath != "$a$a.png" {
+		t.Fatalf("variables not substituted once: %+v", osbFile.Storyboard.Objects)
+	}
+
+	//Every variable doubling the one before it would make the line millions of characters long
+	osbText := "[Variables]\n"
+
+	for letter := 'a'; letter < 'x'; letter++ {
+		osbText += fmt.Sprintf("$%c=$%c$%c\n", letter, letter+1, letter+1)
+	}
+
+	osbText += "$x=x\n\n[Events]\nSprite,Background,Centre,\"$a.png\",0,0\n F,0,0,1000,1,0\nSprite,Background,Centre,\"b.png\",0,0\n"
+
+	osbFile, _ = osu_parser.ParseStoryboardText(osbText)
+
+	//The command of the skipped sprite doesn't end up on any other object
+	if len(osbFile.Diagnostics) != 2 || !errors.Is(osbFile.Diagnostics[0], osu_parser.ErrLimitExceeded) || !errors.Is(osbFile.Diagnostics[1], osu_parser.ErrIncorrectFormatting) {
+		t.Fatalf("expected ErrLimitExceeded and ErrIncorrectFormatting diagnostics, got %v", osbFile.Diagnostics)
+	}
+
+	if len(osbFile.Storyboard.Objects) != 1 || osbFile.Storyboard.Objects[0].FilePath != "b.png" || len(osbFile.Storyboard.Objects[0].Commands) != 0 {
+		t.Fatalf("expected only the second sprite, got %+v", osbFile.Storyboard.Objects)
+	}
+}
+
+func TestParseStoryboardWithOptions(t *testing.T) {
+	osbFile, err := osu_parser.ParseStoryboardWithOptions(strings.NewReader(osbTestText), osu_parser.ParseOptions{})
+	hash := md5.Sum([]byte(osbTestText))
+
+	if err != nil || osbFile.Md5Hash != hex.EncodeToString(hash[:]) || len(osbFile.Storyboard.Objects) != 2 {
+		t.Fatalf("unexpected result: %+v (%v)", osbFile, err)
+	}
+
+	limits := []osu_parser.ParseOptions{
+		{MaxLineLength: 20},
+		{MaxStoryboardObjects: 1},
+		{MaxStoryboardCommands: 1},
+	}
+
+	//Two commands on the first sprite
+	withCommands := osbTestText + " F,0,0,1000,1,0\n"
+
+	for _, options := range limits {
+		if _, err := osu_parser.ParseStoryboardWithOptions(strings.NewReader(withCommands), options); !errors.Is(err, osu_parser.ErrLimitExceeded) {
+			t.Fatalf("%+v: expected ErrLimitExceeded, got %v", options, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := osu_parser.ParseStoryboardWithOptions(strings.NewReader(osbTestText), osu_parser.ParseOptions{Context: ctx}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -81,6 +81,12 @@ type Color struct {
 	B int32
 }
 
+type ColoursSection struct {
+	Combos              []Color
+	SliderBorder        *Color
+	SliderTrackOverride *Color
+}
+
 type Vec2 struct {
 	X float64
 	Y float64
@@ -93,10 +99,134 @@ type Event struct {
 	BackgroundImage string
 	BreakTimeBegin  int32
 	BreakTimeEnd    int32
+	//Background colour set by colour events
+	Colour Color
 }
 
 type EventsSection struct {
-	Events []Event
+	Events     []Event
+	Storyboard Storyboard
+}
+
+type StoryboardLayer int32
+type StoryboardOrigin int32
+type AnimationLoopType int32
+type CommandType int32
+type Easing int32
+
+const (
+	StoryboardLayerBackground StoryboardLayer = 0
+	StoryboardLayerFail       StoryboardLayer = 1
+	StoryboardLayerPass       StoryboardLayer = 2
+	StoryboardLayerForeground StoryboardLayer = 3
+	StoryboardLayerOverlay    StoryboardLayer = 4
+
+	OriginTopLeft      StoryboardOrigin = 0
+	OriginCentre       StoryboardOrigin = 1
+	OriginCentreLeft   StoryboardOrigin = 2
+	OriginTopRight     StoryboardOrigin = 3
+	OriginBottomCentre StoryboardOrigin = 4
+	OriginTopCentre    StoryboardOrigin = 5
+	OriginCustom       StoryboardOrigin = 6
+	OriginCentreRight  StoryboardOrigin = 7
+	OriginBottomLeft   StoryboardOrigin = 8
+	OriginBottomRight  StoryboardOrigin = 9
+
+	AnimationLoopForever AnimationLoopType = 0
+	AnimationLoopOnce    AnimationLoopType = 1
+
+	CommandTypeFade        CommandType = 0
+	CommandTypeMove        CommandType = 1
+	CommandTypeMoveX       CommandType = 2
+	CommandTypeMoveY       CommandType = 3
+	CommandTypeScale       CommandType = 4
+	CommandTypeVectorScale CommandType = 5
+	CommandTypeRotate      CommandType = 6
+	CommandTypeColour      CommandType = 7
+	CommandTypeParameter   CommandType = 8
+	CommandTypeLoop        CommandType = 9
+	CommandTypeTrigger     CommandType = 10
+
+	EasingLinear            Easing = 0
+	EasingOut               Easing = 1
+	EasingIn                Easing = 2
+	EasingInQuad            Easing = 3
+	EasingOutQuad           Easing = 4
+	EasingInOutQuad         Easing = 5
+	EasingInCubic           Easing = 6
+	EasingOutCubic          Easing = 7
+	EasingInOutCubic        Easing = 8
+	EasingInQuart           Easing = 9
+	EasingOutQuart          Easing = 10
+	EasingInOutQuart        Easing = 11
+	EasingInQuint           Easing = 12
+	EasingOutQuint          Easing = 13
+	EasingInOutQuint        Easing = 14
+	EasingInSine            Easing = 15
+	EasingOutSine           Easing = 16
+	EasingInOutSine         Easing = 17
+	EasingInExpo            Easing = 18
+	EasingOutExpo           Easing = 19
+	EasingInOutExpo         Easing = 20
+	EasingInCirc            Easing = 21
+	EasingOutCirc           Easing = 22
+	EasingInOutCirc         Easing = 23
+	EasingInElastic         Easing = 24
+	EasingOutElastic        Easing = 25
+	EasingOutElasticHalf    Easing = 26
+	EasingOutElasticQuarter Easing = 27
+	EasingInOutElastic      Easing = 28
+	EasingInBack            Easing = 29
+	EasingOutBack           Easing = 30
+	EasingInOutBack         Easing = 31
+	EasingInBounce          Easing = 32
+	EasingOutBounce         Easing = 33
+	EasingInOutBounce       Easing = 34
+)
+
+type StoryboardCommand struct {
+	Type        CommandType
+	Easing      Easing
+	StartTime   int32
+	EndTime     int32
+	StartValues []float64
+	EndValues   []float64
+
+	//Parameter specific, one of H, V or A
+	Parameter string
+
+	//Loop specific
+	LoopCount int32
+
+	//Trigger specific
+	TriggerName  string
+	TriggerGroup int32
+
+	//Loop/Trigger children
+	Commands []StoryboardCommand
+}
+
+type StoryboardObject struct {
+	Type     EventType
+	Layer    StoryboardLayer
+	Origin   StoryboardOrigin
+	FilePath string
+	Position Vec2
+
+	//Animation specific
+	FrameCount int32
+	FrameDelay float64
+	LoopType   AnimationLoopType
+
+	//Sample specific
+	Time   int32
+	Volume int32
+
+	Commands []StoryboardCommand
+}
+
+type Storyboard struct {
+	Objects []StoryboardObject
 }
 
 type HitObjectType int32
@@ -158,8 +288,17 @@ type HitObject struct {
 	SampleSets         []SampleSet
 	SampleSetAdditions []SampleSet
 
-	//Spinner/Hold specific
+	//Slider/Spinner/Hold specific
 	EndTime int32
+
+	//Combo numbering, see OsuFile.ApplyCombos
+	ComboIndex            int32
+	IndexInCombo          int32
+	ComboIndexWithOffsets int32
+
+	//osu!standard stacking, see OsuFile.ApplyStacking
+	StackHeight     int32
+	StackedPosition Vec2
 }
 
 type HitObjectsSection struct {
@@ -199,4 +338,7 @@ type TimingPoint struct {
 
 type TimingPointSection struct {
 	TimingPoints []TimingPoint
+
+	//Sorted copies of TimingPoints for the lookups, see Reindex
+	index *timingPointIndex
 }
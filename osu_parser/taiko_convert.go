@@ -0,0 +1,77 @@
+package osu_parser
+
+// Copy of an osu!standard map converted to osu!taiko, the way the client plays it.
+// Whistles and claps turn hits into kats, finishes into big notes, objects at the same time
+// get merged into one big note. Sliders which are too fast to roll get split into hits on every tick,
+// the others and spinners stay as they are, as drumrolls and swells.
+// Difficulty calculation treats the copy like a map made for osu!taiko,
+// convert star ratings come from calling TaikoDifficulty on the original.
+// Slices which don't need to change are shared with the original
+func (osuFile OsuFile) ConvertToTaiko() (OsuFile, error) {
+	if osuFile.General.Mode != PlaymodeOsu {
+		return osuFile, ErrUnsupportedConversion
+	}
+
+	objects := osuFile.taikoObjects()
+	hitObjects := make([]HitObject, 0, len(objects))
+
+	osuFile.HitObjects.CountNormal = 0
+	osuFile.HitObjects.CountSlider = 0
+	osuFile.HitObjects.CountSpinner = 0
+	osuFile.HitObjects.CountHold = 0
+
+	for _, object := range objects {
+		source := &osuFile.HitObjects.List[object.HitObjectIndex]
+		hitObject := *source
+
+		switch {
+		case object.Type == taikoHit && source.Type == HitObjectTypeSlider:
+			hitObject = HitObject{
+				Type:              HitObjectTypeCircle,
+				Position:          source.Position,
+				Time:              object.Time,
+				NewCombo:          source.NewCombo && object.Time == source.Time,
+				HitSound:          source.HitSound,
+				SampleSet:         source.SampleSet,
+				SampleSetAddition: source.SampleSetAddition,
+				CustomSampleSet:   source.CustomSampleSet,
+				Volume:            source.Volume,
+			}
+
+			//Hits keep the sounds of the slider node they came from
+			if object.Node < len(source.SoundTypes) {
+				hitObject.HitSound = source.SoundTypes[object.Node]
+			}
+
+			if object.Node < len(source.SampleSets) {
+				hitObject.SampleSet = source.SampleSets[object.Node]
+			}
+
+			if object.Node < len(source.SampleSetAdditions) {
+				hitObject.SampleSetAddition = source.SampleSetAdditions[object.Node]
+			}
+
+			osuFile.HitObjects.CountNormal++
+		case source.Type == HitObjectTypeSlider:
+			osuFile.HitObjects.CountSlider++
+		case source.Type == HitObjectTypeSpinner:
+			osuFile.HitObjects.CountSpinner++
+		default:
+			osuFile.HitObjects.CountNormal++
+		}
+
+		//Merged objects only become big notes through their sound
+		if object.Strong {
+			hitObject.HitSound |= HitSoundTypeFinish
+		}
+
+		hitObjects = append(hitObjects, hitObject)
+	}
+
+	osuFile.General.Mode = PlaymodeTaiko
+	osuFile.HitObjects.List = hitObjects
+
+	osuFile.computeDerivedValues(nil)
+
+	return osuFile, nil
+}
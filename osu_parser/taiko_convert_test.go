@@ -0,0 +1,136 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestConvertToTaiko(t *testing.T) {
+	//A don, a kat, two circles at the same time, a slider too short to roll and a spinner
+	osuText := "osu file format v14\n\n[General]\nMode: 0\n\n[Difficulty]\nOverallDifficulty:5\nSliderMultiplier:1\nSliderTickRate:1\n\n" +
+		"[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n" +
+		"100,100,0,1,0,0:0:0:0:\n" +
+		"100,100,500,1,2,0:0:0:0:\n" +
+		"100,100,1000,1,0,0:0:0:0:\n" +
+		"200,100,1000,1,0,0:0:0:0:\n" +
+		"100,100,1500,2,0,L|120:100,2,20,4|2|0,0:0|0:0|0:0,0:0:0:0:\n" +
+		"256,192,3000,8,0,4000,0:0:0:0:\n"
+
+	osuFile, err := osu_parser.ParseText(osuText)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taiko, err := osuFile.ConvertToTaiko()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if taiko.General.Mode != osu_parser.PlaymodeTaiko {
+		t.Fatalf("expected an osu!taiko map, got mode %d", taiko.General.Mode)
+	}
+
+	expected := []struct {
+		hitObjectType osu_parser.HitObjectType
+		time          float64
+		hitSound      osu_parser.HitSoundType
+	}{
+		{osu_parser.HitObjectTypeCircle, 0, 0},
+		{osu_parser.HitObjectTypeCircle, 500, osu_parser.HitSoundTypeWhistle},
+		{osu_parser.HitObjectTypeCircle, 1000, osu_parser.HitSoundTypeFinish},
+		{osu_parser.HitObjectTypeCircle, 1500, osu_parser.HitSoundTypeFinish},
+		{osu_parser.HitObjectTypeCircle, 1600, osu_parser.HitSoundTypeWhistle},
+		{osu_parser.HitObjectTypeCircle, 1700, 0},
+		{osu_parser.HitObjectTypeSpinner, 3000, 0},
+	}
+
+	if len(taiko.HitObjects.List) != len(expected) {
+		t.Fatalf("expected %d objects, got %d", len(expected), len(taiko.HitObjects.List))
+	}
+
+	for i, want := range expected {
+		got := taiko.HitObjects.List[i]
+
+		if got.Type != want.hitObjectType || math.Abs(got.Time-want.time) > 0.001 || got.HitSound != want.hitSound {
+			t.Errorf("object %d: expected type %d at %f with sound %d, got type %d at %f with sound %d", i, want.hitObjectType, want.time, want.hitSound, got.Type, got.Time, got.HitSound)
+		}
+	}
+
+	if taiko.HitObjects.CountNormal != 6 || taiko.HitObjects.CountSpinner != 1 || taiko.HitObjects.CountSlider != 0 {
+		t.Fatalf("wrong object counts: %d circles, %d sliders, %d spinners", taiko.HitObjects.CountNormal, taiko.HitObjects.CountSlider, taiko.HitObjects.CountSpinner)
+	}
+
+	//The original stays an osu!standard map
+	if osuFile.General.Mode != osu_parser.PlaymodeOsu || len(osuFile.HitObjects.List) != 6 {
+		t.Fatal("converting changed the original map")
+	}
+
+	if _, err := taiko.ConvertToTaiko(); !errors.Is(err, osu_parser.ErrUnsupportedConversion) {
+		t.Fatalf("expected ErrUnsupportedConversion, got %v", err)
+	}
+}
+
+// Worked out by hand from the conversion rules, at 140 osu!pixels a beat in osu!taiko and 280 in osu!standard:
+// 300 long takes 1500ms to roll which isn't under two beats so it stays a drumroll,
+// 50 long over two spans takes 500ms and gets split into hits every 250ms with the sounds of its nodes
+func TestConvertToTaikoSliders(t *testing.T) {
+	osuText := "osu file format v14\n\n[General]\nMode: 0\n\n[Difficulty]\nSliderMultiplier:1\nSliderTickRate:1\n\n" +
+		"[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n" +
+		"100,100,0,2,0,L|400:100,1,300,0|0,0:0|0:0,0:0:0:0:\n" +
+		"100,100,2000,2,0,L|150:100,2,50,2|8|4,0:0|0:0|2:0,0:0:0:0:\n"
+
+	osuFile, _ := osu_parser.ParseText(osuText)
+
+	taiko, err := osuFile.ConvertToTaiko()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Going through the encoder makes sure the conversion survives being saved
+	reparsed, err := osu_parser.ParseText(taiko.Encode())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		hitObjectType osu_parser.HitObjectType
+		time          float64
+		hitSound      osu_parser.HitSoundType
+		sampleSet     osu_parser.SampleSet
+	}{
+		{osu_parser.HitObjectTypeSlider, 0, 0, 0},
+		{osu_parser.HitObjectTypeCircle, 2000, osu_parser.HitSoundTypeWhistle, 0},
+		{osu_parser.HitObjectTypeCircle, 2250, osu_parser.HitSoundTypeClap, 0},
+		{osu_parser.HitObjectTypeCircle, 2500, osu_parser.HitSoundTypeFinish, osu_parser.SampleSetSoft},
+	}
+
+	for _, taikoFile := range []osu_parser.OsuFile{taiko, reparsed} {
+		if len(taikoFile.HitObjects.List) != len(expected) {
+			t.Fatalf("expected %d objects, got %d", len(expected), len(taikoFile.HitObjects.List))
+		}
+
+		for i, want := range expected {
+			got := taikoFile.HitObjects.List[i]
+
+			if got.Type != want.hitObjectType || math.Abs(got.Time-want.time) > 0.001 || got.HitSound != want.hitSound || got.SampleSet != want.sampleSet {
+				t.Errorf("object %d: expected type %d at %f with sound %d and set %d, got %+v", i, want.hitObjectType, want.time, want.hitSound, want.sampleSet, got)
+			}
+		}
+
+		if drumroll := taikoFile.HitObjects.List[0]; drumroll.SliderLength != 300 || drumroll.RepeatCount != 1 {
+			t.Errorf("drumroll changed: %+v", drumroll)
+		}
+
+		//Only the hits give combo
+		if taikoFile.MaxCombo() != 3 {
+			t.Errorf("expected max combo 3, got %d", taikoFile.MaxCombo())
+		}
+	}
+}
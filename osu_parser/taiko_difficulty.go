@@ -0,0 +1,710 @@
+package osu_parser
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	taikoDifficultyMultiplier = 0.084375
+	taikoRhythmMultiplier     = 0.2 * taikoDifficultyMultiplier
+	taikoColourMultiplier     = 0.375 * taikoDifficultyMultiplier
+	taikoStaminaMultiplier    = 0.375 * taikoDifficultyMultiplier
+
+	colourSkillMultiplier  = 0.12
+	colourStrainDecayBase  = 0.8
+	rhythmSkillMultiplier  = 10.0
+	rhythmStrainDecay      = 0.96
+	rhythmHistoryMaxLength = 8
+	staminaSkillMultiplier = 1.1
+	staminaStrainDecayBase = 0.4
+
+	//Colour patterns repeating after more than this many patterns don't count as repeating
+	maxRepetitionInterval = 16
+)
+
+type TaikoDifficultyAttributes struct {
+	StarRating float64
+
+	StaminaDifficulty float64
+	RhythmDifficulty  float64
+	ColourDifficulty  float64
+	//Combined difficulty of the three skills, before scaling it to a star rating
+	PeakDifficulty float64
+
+	//Hit window of a great judgement in milliseconds, either side of the note
+	GreatHitWindow float64
+	MaxCombo       int
+}
+
+type taikoObjectType int
+
+const (
+	taikoHit taikoObjectType = iota
+	taikoDrumroll
+	taikoSwell
+)
+
+type taikoHitType int
+
+const (
+	//Drumrolls and swells are neither
+	taikoHitTypeNone taikoHitType = iota
+	taikoHitTypeCentre
+	taikoHitTypeRim
+)
+
+// An object as it's played in osu!taiko
+type taikoObject struct {
+	Type     taikoObjectType
+	HitType  taikoHitType
+	Time     float64
+	Duration float64
+	//Big notes, or objects which were at the same time as another in the converted map
+	Strong bool
+	//Index of the hit object this came from
+	HitObjectIndex int
+	//Slider node whose sound a hit got, for sliders which turned into hits
+	Node int
+}
+
+func taikoHitTypeOf(sound HitSoundType) taikoHitType {
+	if sound&(HitSoundTypeWhistle|HitSoundTypeClap) != 0 {
+		return taikoHitTypeRim
+	}
+
+	return taikoHitTypeCentre
+}
+
+// The map as osu!taiko plays it. osu!standard maps get converted:
+// short sliders turn into hits, objects at the same time get merged into one strong object
+func (osuFile *OsuFile) taikoObjects() []taikoObject {
+	converted := osuFile.General.Mode != PlaymodeTaiko
+	objects := []taikoObject{}
+
+	for i := range osuFile.HitObjects.List {
+		hitObject := &osuFile.HitObjects.List[i]
+
+		switch hitObject.Type {
+		case HitObjectTypeSlider:
+			duration, tickSpacing, convertsToHits := osuFile.taikoSliderConversion(hitObject)
+
+			if !converted || !convertsToHits {
+				objects = append(objects, taikoObject{
+					Type:           taikoDrumroll,
+					Time:           hitObject.Time,
+					Duration:       duration,
+					Strong:         hitObject.HitSound&HitSoundTypeFinish != 0,
+					HitObjectIndex: i,
+				})
+
+				continue
+			}
+
+			//Every tick gets the sound of the next slider node, going back to the head once they run out
+			nodeSounds := append([]HitSoundType{}, hitObject.SoundTypes...)
+
+			if len(nodeSounds) == 0 {
+				nodeSounds = []HitSoundType{hitObject.HitSound}
+			}
+
+			node := 0
+
+			for time := hitObject.Time; time <= hitObject.Time+duration+tickSpacing/8; time += tickSpacing {
+				objects = append(objects, taikoObject{
+					Type:           taikoHit,
+					HitType:        taikoHitTypeOf(nodeSounds[node]),
+					Time:           time,
+					Strong:         nodeSounds[node]&HitSoundTypeFinish != 0,
+					HitObjectIndex: i,
+					Node:           node,
+				})
+
+				node = (node + 1) % len(nodeSounds)
+			}
+		case HitObjectTypeSpinner, HitObjectTypeHold:
+			objects = append(objects, taikoObject{
+				Type:           taikoSwell,
+				Time:           hitObject.Time,
+				Duration:       float64(hitObject.EndTime) - hitObject.Time,
+				HitObjectIndex: i,
+			})
+		default:
+			objects = append(objects, taikoObject{
+				Type:           taikoHit,
+				HitType:        taikoHitTypeOf(hitObject.HitSound),
+				Time:           hitObject.Time,
+				Strong:         hitObject.HitSound&HitSoundTypeFinish != 0,
+				HitObjectIndex: i,
+			})
+		}
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].Time < objects[j].Time
+	})
+
+	if !converted {
+		return objects
+	}
+
+	merged := []taikoObject{}
+
+	for _, object := range objects {
+		if len(merged) != 0 && merged[len(merged)-1].Time == object.Time {
+			//Swells can't be strong
+			if merged[len(merged)-1].Type != taikoSwell {
+				merged[len(merged)-1].Strong = true
+			}
+
+			continue
+		}
+
+		merged = append(merged, object)
+	}
+
+	return merged
+}
+
+// How the time to the previous object relates to the time between the two before it
+type taikoRhythm struct {
+	ratio      float64
+	difficulty float64
+}
+
+var taikoCommonRhythms = []taikoRhythm{
+	{1.0 / 1, 0.0},
+	{2.0 / 1, 0.3},
+	{1.0 / 2, 0.5},
+	{3.0 / 1, 0.3},
+	{1.0 / 3, 0.35},
+	{3.0 / 2, 0.6},
+	{2.0 / 3, 0.4},
+	{5.0 / 4, 0.5},
+	{4.0 / 5, 0.7},
+}
+
+// Notes of one colour in a row
+type taikoMonoStreak struct {
+	objects []*taikoDifficultyObject
+	parent  *taikoAlternatingMonoPattern
+	//Position within the parent
+	index int
+}
+
+func (streak *taikoMonoStreak) hitType() taikoHitType {
+	return streak.objects[0].object.HitType
+}
+
+// Mono streaks of the same length, alternating colours
+type taikoAlternatingMonoPattern struct {
+	streaks []*taikoMonoStreak
+	parent  *taikoRepeatingHitPatterns
+	index   int
+}
+
+func (pattern *taikoAlternatingMonoPattern) firstObject() *taikoDifficultyObject {
+	return pattern.streaks[0].objects[0]
+}
+
+func (pattern *taikoAlternatingMonoPattern) hasIdenticalMonoLength(other *taikoAlternatingMonoPattern) bool {
+	return len(other.streaks[0].objects) == len(pattern.streaks[0].objects)
+}
+
+func (pattern *taikoAlternatingMonoPattern) isRepetitionOf(other *taikoAlternatingMonoPattern) bool {
+	return pattern.hasIdenticalMonoLength(other) && len(other.streaks) == len(pattern.streaks) && other.streaks[0].hitType() == pattern.streaks[0].hitType()
+}
+
+// Alternating mono patterns which repeat each other
+type taikoRepeatingHitPatterns struct {
+	patterns []*taikoAlternatingMonoPattern
+	previous *taikoRepeatingHitPatterns
+	//How many patterns ago the same one came up
+	repetitionInterval int
+}
+
+func (patterns *taikoRepeatingHitPatterns) isRepetitionOf(other *taikoRepeatingHitPatterns) bool {
+	if len(patterns.patterns) != len(other.patterns) {
+		return false
+	}
+
+	for i := 0; i < min(len(patterns.patterns), 2); i++ {
+		if !patterns.patterns[i].hasIdenticalMonoLength(other.patterns[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (patterns *taikoRepeatingHitPatterns) findRepetitionInterval() {
+	patterns.repetitionInterval = maxRepetitionInterval + 1
+
+	other := patterns.previous
+
+	for interval := 1; other != nil && interval < maxRepetitionInterval; interval++ {
+		if patterns.isRepetitionOf(other) {
+			patterns.repetitionInterval = interval
+			return
+		}
+
+		other = other.previous
+	}
+}
+
+type taikoDifficultyObject struct {
+	index   int
+	objects []*taikoDifficultyObject
+
+	object    *taikoObject
+	startTime float64
+	deltaTime float64
+	rhythm    int
+
+	//Position among the notes of the same colour, and among all notes
+	monoIndex   int
+	monoObjects *[]*taikoDifficultyObject
+	noteIndex   int
+	noteObjects *[]*taikoDifficultyObject
+
+	monoStreak       *taikoMonoStreak
+	monoPattern      *taikoAlternatingMonoPattern
+	repeatingPattern *taikoRepeatingHitPatterns
+}
+
+func (object *taikoDifficultyObject) isHit() bool {
+	return object.object.Type == taikoHit
+}
+
+func (object *taikoDifficultyObject) previous(backwards int) *taikoDifficultyObject {
+	index := object.index - (backwards + 1)
+
+	if index < 0 {
+		return nil
+	}
+
+	return object.objects[index]
+}
+
+func (object *taikoDifficultyObject) previousMono(backwards int) *taikoDifficultyObject {
+	index := object.monoIndex - (backwards + 1)
+
+	if object.monoObjects == nil || index < 0 {
+		return nil
+	}
+
+	return (*object.monoObjects)[index]
+}
+
+func (object *taikoDifficultyObject) previousNote(backwards int) *taikoDifficultyObject {
+	index := object.noteIndex - (backwards + 1)
+
+	if index < 0 {
+		return nil
+	}
+
+	return (*object.noteObjects)[index]
+}
+
+// Star rating and difficulty attributes for an osu!taiko map, or an osu!standard map converted to osu!taiko.
+// Mods changing the map are applied with ApplyMods, so a map which already had them applied should be given ModsNone
+func (osuFile *OsuFile) TaikoDifficulty(mods Mods) (TaikoDifficultyAttributes, error) {
+	attributes := TaikoDifficultyAttributes{}
+	converted := osuFile.General.Mode != PlaymodeTaiko
+
+	if converted && osuFile.General.Mode != PlaymodeOsu {
+		return attributes, ErrUnsupportedConversion
+	}
+
+	modded, err := osuFile.ApplyMods(mods)
+
+	if err != nil {
+		return attributes, err
+	}
+
+	osuFile = &modded
+
+	if err := checkHitObjectTimes(osuFile.HitObjects.List); err != nil {
+		return attributes, err
+	}
+
+	objects := osuFile.taikoObjects()
+
+	attributes.GreatHitWindow = osuFile.Difficulty.HitWindows(PlaymodeTaiko).Great
+
+	for _, object := range objects {
+		if object.Type == taikoHit {
+			attributes.MaxCombo++
+		}
+	}
+
+	if len(objects) == 0 {
+		return attributes, nil
+	}
+
+	difficultyObjects := taikoDifficultyObjects(objects)
+
+	colourPeaks := strainPeaks{}
+	rhythmPeaks := strainPeaks{}
+	staminaPeaks := strainPeaks{}
+
+	colourStrain := 0.0
+	staminaStrain := 0.0
+	rhythm := taikoRhythmSkill{}
+
+	for _, object := range difficultyObjects {
+		colourPeaks.process(object.index, object.startTime, func() float64 {
+			colourStrain *= strainDecay(colourStrainDecayBase, object.deltaTime)
+			colourStrain += evaluateColour(object) * colourSkillMultiplier
+
+			return colourStrain
+		}, func(time float64) float64 {
+			return colourStrain * strainDecay(colourStrainDecayBase, time-object.previous(0).startTime)
+		})
+
+		rhythmPeaks.process(object.index, object.startTime, func() float64 {
+			return rhythm.strainValueAt(object)
+		}, func(time float64) float64 {
+			return rhythm.strain * strainDecay(0, time-object.previous(0).startTime)
+		})
+
+		staminaPeaks.process(object.index, object.startTime, func() float64 {
+			staminaStrain *= strainDecay(staminaStrainDecayBase, object.deltaTime)
+			staminaStrain += evaluateStamina(object) * staminaSkillMultiplier
+
+			return staminaStrain
+		}, func(time float64) float64 {
+			return staminaStrain * strainDecay(staminaStrainDecayBase, time-object.previous(0).startTime)
+		})
+	}
+
+	attributes.ColourDifficulty = weightedStrainSum(colourPeaks.all(), 0.9) * taikoColourMultiplier
+	attributes.RhythmDifficulty = weightedStrainSum(rhythmPeaks.all(), 0.9) * taikoRhythmMultiplier
+	attributes.StaminaDifficulty = weightedStrainSum(staminaPeaks.all(), 0.9) * taikoStaminaMultiplier
+	attributes.PeakDifficulty = taikoCombinedDifficulty(colourPeaks.all(), rhythmPeaks.all(), staminaPeaks.all())
+
+	attributes.StarRating = taikoRescale(attributes.PeakDifficulty * 1.4)
+
+	//Converts can be played with more than two keys per colour, which is easier than the map looks
+	if converted {
+		attributes.StarRating *= 0.925
+
+		if attributes.ColourDifficulty < 2 && attributes.StaminaDifficulty > 8 {
+			attributes.StarRating *= 0.8
+		}
+	}
+
+	return attributes, nil
+}
+
+// Difficulty objects start from the third object, the rhythm needs the two before it
+func taikoDifficultyObjects(objects []taikoObject) []*taikoDifficultyObject {
+	difficultyObjects := []*taikoDifficultyObject{}
+	centreObjects := []*taikoDifficultyObject{}
+	rimObjects := []*taikoDifficultyObject{}
+	noteObjects := []*taikoDifficultyObject{}
+
+	for i := 2; i < len(objects); i++ {
+		object := &taikoDifficultyObject{
+			index:       len(difficultyObjects),
+			object:      &objects[i],
+			startTime:   objects[i].Time,
+			deltaTime:   objects[i].Time - objects[i-1].Time,
+			noteObjects: &noteObjects,
+		}
+
+		//Picks the common rhythm closest to the actual one
+		ratio := object.deltaTime / (objects[i-1].Time - objects[i-2].Time)
+
+		for j, rhythm := range taikoCommonRhythms {
+			if math.Abs(rhythm.ratio-ratio) < math.Abs(taikoCommonRhythms[object.rhythm].ratio-ratio) {
+				object.rhythm = j
+			}
+		}
+
+		switch objects[i].HitType {
+		case taikoHitTypeCentre:
+			object.monoIndex = len(centreObjects)
+			object.monoObjects = &centreObjects
+			centreObjects = append(centreObjects, object)
+		case taikoHitTypeRim:
+			object.monoIndex = len(rimObjects)
+			object.monoObjects = &rimObjects
+			rimObjects = append(rimObjects, object)
+		}
+
+		if object.isHit() {
+			object.noteIndex = len(noteObjects)
+			noteObjects = append(noteObjects, object)
+		}
+
+		difficultyObjects = append(difficultyObjects, object)
+	}
+
+	for _, object := range difficultyObjects {
+		object.objects = difficultyObjects
+	}
+
+	encodeTaikoColours(difficultyObjects)
+
+	return difficultyObjects
+}
+
+// Groups the objects into mono streaks, those into alternating mono patterns and those into repeating hit patterns
+func encodeTaikoColours(objects []*taikoDifficultyObject) {
+	streaks := []*taikoMonoStreak{}
+
+	for _, object := range objects {
+		previous := object.previousNote(0)
+
+		if len(streaks) == 0 || previous == nil || object.object.HitType != previous.object.HitType {
+			streaks = append(streaks, &taikoMonoStreak{})
+		}
+
+		streak := streaks[len(streaks)-1]
+		streak.objects = append(streak.objects, object)
+	}
+
+	monoPatterns := []*taikoAlternatingMonoPattern{}
+	currentMonoPattern := &taikoAlternatingMonoPattern{}
+
+	for i, streak := range streaks {
+		currentMonoPattern.streaks = append(currentMonoPattern.streaks, streak)
+
+		if i == len(streaks)-1 || len(currentMonoPattern.streaks[0].objects) != len(streaks[i+1].objects) {
+			monoPatterns = append(monoPatterns, currentMonoPattern)
+			currentMonoPattern = &taikoAlternatingMonoPattern{}
+		}
+	}
+
+	hitPatterns := []*taikoRepeatingHitPatterns{}
+	var currentHitPatterns *taikoRepeatingHitPatterns
+
+	for i := 0; i < len(monoPatterns); i++ {
+		currentHitPatterns = &taikoRepeatingHitPatterns{previous: currentHitPatterns}
+
+		isCoupled := func() bool {
+			return i < len(monoPatterns)-2 && monoPatterns[i].isRepetitionOf(monoPatterns[i+2])
+		}
+
+		if !isCoupled() {
+			currentHitPatterns.patterns = append(currentHitPatterns.patterns, monoPatterns[i])
+		} else {
+			for isCoupled() {
+				currentHitPatterns.patterns = append(currentHitPatterns.patterns, monoPatterns[i])
+				i++
+			}
+
+			//The two patterns after the last coupled one belong to it as well
+			currentHitPatterns.patterns = append(currentHitPatterns.patterns, monoPatterns[i], monoPatterns[i+1])
+			i++
+		}
+
+		hitPatterns = append(hitPatterns, currentHitPatterns)
+	}
+
+	for _, hitPattern := range hitPatterns {
+		hitPattern.findRepetitionInterval()
+
+		for i, monoPattern := range hitPattern.patterns {
+			monoPattern.parent = hitPattern
+			monoPattern.index = i
+
+			for j, streak := range monoPattern.streaks {
+				streak.parent = monoPattern
+				streak.index = j
+
+				for _, object := range streak.objects {
+					object.monoStreak = streak
+					object.monoPattern = monoPattern
+					object.repeatingPattern = hitPattern
+				}
+			}
+		}
+	}
+}
+
+func colourSigmoid(value float64, center float64, width float64, middle float64, height float64) float64 {
+	return math.Tanh(math.E*-(value-center)/width)*(height/2) + middle
+}
+
+func (patterns *taikoRepeatingHitPatterns) difficulty() float64 {
+	return 2 * (1 - colourSigmoid(float64(patterns.repetitionInterval), 2, 2, 0.5, 1))
+}
+
+func (pattern *taikoAlternatingMonoPattern) difficulty() float64 {
+	return colourSigmoid(float64(pattern.index), 2, 2, 0.5, 1) * pattern.parent.difficulty()
+}
+
+func (streak *taikoMonoStreak) difficulty() float64 {
+	return colourSigmoid(float64(streak.index), 2, 2, 0.5, 1) * streak.parent.difficulty() * 0.5
+}
+
+// Only the first object of every colour pattern is difficult, and the more the pattern repeats the easier it gets
+func evaluateColour(object *taikoDifficultyObject) float64 {
+	difficulty := 0.0
+
+	if object.monoStreak.objects[0] == object {
+		difficulty += object.monoStreak.difficulty()
+	}
+
+	if object.monoPattern.firstObject() == object {
+		difficulty += object.monoPattern.difficulty()
+	}
+
+	if object.repeatingPattern.patterns[0].firstObject() == object {
+		difficulty += object.repeatingPattern.difficulty()
+	}
+
+	return difficulty
+}
+
+// Hitting the same key again, the faster the harder
+func evaluateStamina(object *taikoDifficultyObject) float64 {
+	if !object.isHit() {
+		return 0
+	}
+
+	keyPrevious := object.previousMono(1)
+
+	if keyPrevious == nil {
+		return 0
+	}
+
+	return 0.5 + 30/math.Max(1, object.startTime-keyPrevious.startTime)
+}
+
+type taikoRhythmSkill struct {
+	strain                 float64
+	currentStrain          float64
+	notesSinceRhythmChange int
+	history                []*taikoDifficultyObject
+}
+
+func (skill *taikoRhythmSkill) strainValueAt(object *taikoDifficultyObject) float64 {
+	//Rhythm strain doesn't carry over between objects, the skill keeps its own strain instead
+	skill.strain *= strainDecay(0, object.deltaTime)
+	skill.strain += skill.strainValueOf(object) * rhythmSkillMultiplier
+
+	return skill.strain
+}
+
+func (skill *taikoRhythmSkill) reset() {
+	skill.currentStrain = 0
+	skill.notesSinceRhythmChange = 0
+}
+
+func (skill *taikoRhythmSkill) strainValueOf(object *taikoDifficultyObject) float64 {
+	//Drumrolls and swells don't have any rhythm
+	if !object.isHit() {
+		skill.reset()
+		return 0
+	}
+
+	skill.currentStrain *= rhythmStrainDecay
+	skill.notesSinceRhythmChange++
+
+	rhythmDifficulty := taikoCommonRhythms[object.rhythm].difficulty
+
+	if rhythmDifficulty == 0 {
+		return 0
+	}
+
+	objectStrain := rhythmDifficulty
+	objectStrain *= skill.repetitionPenalties(object)
+	objectStrain *= rhythmPatternLengthPenalty(skill.notesSinceRhythmChange)
+	objectStrain *= skill.speedPenalty(object.deltaTime)
+
+	skill.notesSinceRhythmChange = 0
+	skill.currentStrain += objectStrain
+
+	return skill.currentStrain
+}
+
+// Rhythms which came up recently are easier, looking at the last 2 to 4 rhythm changes
+func (skill *taikoRhythmSkill) repetitionPenalties(object *taikoDifficultyObject) float64 {
+	penalty := 1.0
+
+	skill.history = append(skill.history, object)
+
+	if len(skill.history) > rhythmHistoryMaxLength {
+		skill.history = skill.history[1:]
+	}
+
+	for patternLength := 2; patternLength <= rhythmHistoryMaxLength/2; patternLength++ {
+		for start := len(skill.history) - patternLength - 1; start >= 0; start-- {
+			if !skill.samePattern(start, patternLength) {
+				continue
+			}
+
+			notesSince := object.index - skill.history[start].index
+			penalty *= math.Min(1, 0.032*float64(notesSince))
+
+			break
+		}
+	}
+
+	return penalty
+}
+
+func (skill *taikoRhythmSkill) samePattern(start int, patternLength int) bool {
+	for i := 0; i < patternLength; i++ {
+		if skill.history[start+i].rhythm != skill.history[len(skill.history)-patternLength+i].rhythm {
+			return false
+		}
+	}
+
+	return true
+}
+
+func rhythmPatternLengthPenalty(patternLength int) float64 {
+	shortPatternPenalty := math.Min(0.15*float64(patternLength), 1)
+	longPatternPenalty := math.Max(0, math.Min(1, 2.5-0.15*float64(patternLength)))
+
+	return math.Min(shortPatternPenalty, longPatternPenalty)
+}
+
+// Rhythm changes on slow notes are easy, slow enough ones reset the strain
+func (skill *taikoRhythmSkill) speedPenalty(deltaTime float64) float64 {
+	if deltaTime < 80 {
+		return 1
+	}
+
+	if deltaTime < 210 {
+		return math.Max(0, 1.4-0.005*deltaTime)
+	}
+
+	skill.reset()
+
+	return 0
+}
+
+func norm(p float64, values ...float64) float64 {
+	sum := 0.0
+
+	for _, value := range values {
+		sum += math.Pow(value, p)
+	}
+
+	return math.Pow(sum, 1/p)
+}
+
+// Combines the skills section by section, colour and stamina first, then rhythm
+func taikoCombinedDifficulty(colourPeaks []float64, rhythmPeaks []float64, staminaPeaks []float64) float64 {
+	peaks := make([]float64, len(colourPeaks))
+
+	for i := range colourPeaks {
+		peak := norm(1.5, colourPeaks[i]*taikoColourMultiplier, staminaPeaks[i]*taikoStaminaMultiplier)
+		peaks[i] = norm(2, peak, rhythmPeaks[i]*taikoRhythmMultiplier)
+	}
+
+	return weightedStrainSum(peaks, 0.9)
+}
+
+func taikoRescale(starRating float64) float64 {
+	if starRating < 0 {
+		return starRating
+	}
+
+	return 10.43 * math.Log(starRating/8+1)
+}
@@ -0,0 +1,99 @@
+package osu_parser_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestTaikoDifficultyConvert(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	attributes, err := parsedOsuFile.TaikoDifficulty(osu_parser.ModsNone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][2]float64{
+		"star rating": {attributes.StarRating, 2.9548},
+		"stamina":     {attributes.StaminaDifficulty, 1.4592},
+		"rhythm":      {attributes.RhythmDifficulty, 0.2287},
+		"colour":      {attributes.ColourDifficulty, 1.1267},
+		"great":       {attributes.GreatHitWindow, 32},
+	}
+
+	for name, values := range expected {
+		if math.Abs(values[0]-values[1]) > 0.0001 {
+			t.Fatalf("expected %s %f, got %f", name, values[1], values[0])
+		}
+	}
+
+	maxCombo, _ := parsedOsuFile.MaxComboFor(osu_parser.PlaymodeTaiko)
+
+	if attributes.MaxCombo != maxCombo {
+		t.Fatalf("expected max combo %d, got %d", maxCombo, attributes.MaxCombo)
+	}
+}
+
+func TestTaikoDifficultyColours(t *testing.T) {
+	//Hit sounds 0 are dons, 2 (whistle) are kats
+	taikoMap := func(pattern []int) string {
+		text := "osu file format v14\n\n[General]\nMode: 1\n\n[Difficulty]\nOverallDifficulty:5\n\n[TimingPoints]\n0,500,4,2,0,100,1,0\n\n[HitObjects]\n"
+
+		for i := 0; i < 128; i++ {
+			text += fmt.Sprintf("256,192,%d,1,%d\n", i*125, pattern[i%len(pattern)])
+		}
+
+		return text
+	}
+
+	mono, _ := osu_parser.ParseText(taikoMap([]int{0}))
+	mixed, _ := osu_parser.ParseText(taikoMap([]int{0, 2, 2, 0, 2, 0, 0, 0, 2}))
+
+	monoAttributes, _ := mono.TaikoDifficulty(osu_parser.ModsNone)
+	mixedAttributes, _ := mixed.TaikoDifficulty(osu_parser.ModsNone)
+
+	if !(mixedAttributes.ColourDifficulty > monoAttributes.ColourDifficulty) || !(mixedAttributes.StarRating > monoAttributes.StarRating) {
+		t.Fatalf("mixed colours aren't harder: %f against %f", mixedAttributes.StarRating, monoAttributes.StarRating)
+	}
+
+	if monoAttributes.MaxCombo != 128 || monoAttributes.GreatHitWindow != 35 {
+		t.Fatalf("wrong attributes: %+v", monoAttributes)
+	}
+}
+
+func TestTaikoDifficultyMods(t *testing.T) {
+	parsedOsuFile, _ := osu_parser.ParseFile("../cases/COOL&CREATE - サトリムソウ (Furball) [Insane].osu")
+
+	attributes, _ := parsedOsuFile.TaikoDifficulty(osu_parser.ModsNone)
+	fastAttributes, err := parsedOsuFile.TaikoDifficulty(osu_parser.ModsDoubleTime)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The hit window is the one which plays the same at normal speed
+	if !(fastAttributes.StarRating > attributes.StarRating) || !(fastAttributes.GreatHitWindow < attributes.GreatHitWindow) {
+		t.Fatalf("DoubleTime didn't make the map harder: %+v against %+v", fastAttributes, attributes)
+	}
+
+	if again, _ := parsedOsuFile.TaikoDifficulty(osu_parser.ModsNone); again != attributes {
+		t.Fatalf("applying mods changed the map: %+v against %+v", again, attributes)
+	}
+
+	if _, err := parsedOsuFile.TaikoDifficulty(osu_parser.ModsDoubleTime | osu_parser.ModsHalfTime); !errors.Is(err, osu_parser.ErrIncompatibleMods) {
+		t.Fatalf("expected ErrIncompatibleMods, got %v", err)
+	}
+}
+
+func TestTaikoDifficultyUnsupported(t *testing.T) {
+	mania, _ := osu_parser.ParseText("osu file format v14\n\n[General]\nMode: 3\n")
+
+	if _, err := mania.TaikoDifficulty(osu_parser.ModsNone); !errors.Is(err, osu_parser.ErrUnsupportedConversion) {
+		t.Fatalf("expected ErrUnsupportedConversion, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package osu_parser
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	defaultBeatLength = 1000.0
+)
+
+type timingPointIndex struct {
+	//Uninherited timing points (red lines) by offset
+	timing []TimingPoint
+	//All timing points by offset, red lines before green lines on the same offset
+	control []TimingPoint
+
+	//The list the index was built from, replacing or resizing TimingPoints makes it out of date
+	source *TimingPoint
+	count  int
+}
+
+func newTimingPointIndex(timingPoints []TimingPoint) *timingPointIndex {
+	index := &timingPointIndex{
+		control: append([]TimingPoint{}, timingPoints...),
+		count:   len(timingPoints),
+	}
+
+	if len(timingPoints) != 0 {
+		index.source = &timingPoints[0]
+	}
+
+	//Like the game, points on the same offset keep the order they were written in
+	sort.SliceStable(index.control, func(a, b int) bool {
+		if index.control[a].Offset != index.control[b].Offset {
+			return index.control[a].Offset < index.control[b].Offset
+		}
+
+		return !index.control[a].InheritedTimingPoint && index.control[b].InheritedTimingPoint
+	})
+
+	for _, timingPoint := range index.control {
+		if !timingPoint.InheritedTimingPoint {
+			index.timing = append(index.timing, timingPoint)
+		}
+	}
+
+	return index
+}
+
+// Rebuilds the sorted lists the lookups go through, parsing builds them already.
+// Assigning a new list or appending to it is picked up without this, just slower,
+// changing a timing point in place needs a Reindex before the lookups see it
+func (section *TimingPointSection) Reindex() {
+	section.index = newTimingPointIndex(section.TimingPoints)
+}
+
+// Lookups never write the index, so they are safe to call from several goroutines at once
+func (section *TimingPointSection) sorted() *timingPointIndex {
+	index := section.index
+
+	if index == nil || index.count != len(section.TimingPoints) || (index.count != 0 && index.source != &section.TimingPoints[0]) {
+		return newTimingPointIndex(section.TimingPoints)
+	}
+
+	return index
+}
+
+// Index of the last point at or before time, -1 if time is before all of them
+func lastAt(timingPoints []TimingPoint, time float64) int {
+	return sort.Search(len(timingPoints), func(i int) bool {
+		return timingPoints[i].Offset > time
+	}) - 1
+}
+
+// The uninherited timing point (red line) active at time.
+// Before the first one, the first one applies
+func (section *TimingPointSection) TimingPointAt(time float64) TimingPoint {
+	timing := section.sorted().timing
+
+	if len(timing) == 0 {
+		return TimingPoint{
+			BeatLength: defaultBeatLength,
+			SampleSet:  SampleSetNormal,
+			Volume:     100,
+		}
+	}
+
+	return timing[max(0, lastAt(timing, time))]
+}
+
+// The timing point of either kind active at time, which decides sample set, volume and kiai.
+// Before the first one, the first one applies
+func (section *TimingPointSection) ControlPointAt(time float64) TimingPoint {
+	control := section.sorted().control
+
+	if len(control) == 0 {
+		return section.TimingPointAt(time)
+	}
+
+	return control[max(0, lastAt(control, time))]
+}
+
+func (section *TimingPointSection) BeatLengthAt(time float64) float64 {
+	beatLength := section.TimingPointAt(time).BeatLength
+
+	if math.IsNaN(beatLength) {
+		return defaultBeatLength
+	}
+
+	return math.Max(6, math.Min(60000, beatLength))
+}
+
+func (section *TimingPointSection) BpmAt(time float64) float64 {
+	return 60000 / section.BeatLengthAt(time)
+}
+
+// Slider velocity multiplier from the green line active at time, 1 if there is none
+func (section *TimingPointSection) SliderVelocityAt(time float64) float64 {
+	control := section.sorted().control
+	index := lastAt(control, time)
+
+	if index == -1 || !control[index].InheritedTimingPoint || !(control[index].BeatLength < 0) {
+		return 1
+	}
+
+	return math.Max(0.1, math.Min(10, -100/control[index].BeatLength))
+}
+
+func (section *TimingPointSection) IsKiaiAt(time float64) bool {
+	control := section.sorted().control
+	index := lastAt(control, time)
+
+	return index != -1 && control[index].SpecialFlag&SpecialKiai != 0
+}
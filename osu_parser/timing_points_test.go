@@ -0,0 +1,92 @@
+package osu_parser_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Waffle-osu/osu-parser/osu_parser"
+)
+
+func TestTimingPointLookups(t *testing.T) {
+	//Written out of order, with a green line before the red line on the same offset
+	parsedOsuFile, _ := osu_parser.ParseText("osu file format v14\n\n[TimingPoints]\n2000,-50,4,1,0,60,0,1\n2000,250,4,2,0,80,1,0\n1000,500,4,2,0,70,1,0\n1500,-200,4,3,0,50,0,0\n")
+
+	timing := &parsedOsuFile.TimingPoints
+
+	if timing.BeatLengthAt(0) != 500 || timing.BeatLengthAt(1999) != 500 || timing.BeatLengthAt(2000) != 250 || timing.BpmAt(3000) != 240 {
+		t.Fatal("wrong beat lengths")
+	}
+
+	if timing.SliderVelocityAt(0) != 1 || timing.SliderVelocityAt(1000) != 1 || timing.SliderVelocityAt(1500) != 0.5 || timing.SliderVelocityAt(2000) != 2 {
+		t.Fatal("wrong slider velocities")
+	}
+
+	if timing.ControlPointAt(0).Volume != 70 || timing.ControlPointAt(1600).SampleSet != osu_parser.SampleSetDrum || timing.ControlPointAt(2500).Volume != 60 {
+		t.Fatal("wrong control points")
+	}
+
+	if timing.IsKiaiAt(1999) || !timing.IsKiaiAt(2000) {
+		t.Fatal("wrong kiai")
+	}
+
+	//New timing points get picked up without reindexing
+	timing.TimingPoints = append(timing.TimingPoints, osu_parser.TimingPoint{Offset: 3000, BeatLength: 1000})
+
+	if timing.BeatLengthAt(3000) != 1000 {
+		t.Fatal("appended timing point not picked up")
+	}
+
+	//A different list with the same length doesn't reuse the old index either
+	timing.TimingPoints = []osu_parser.TimingPoint{{Offset: 0, BeatLength: 300}, {Offset: 1000, BeatLength: 400}, {Offset: 2000, BeatLength: 500}, {Offset: 3000, BeatLength: 600}, {Offset: 4000, BeatLength: 700}}
+
+	if timing.BeatLengthAt(1000) != 400 {
+		t.Fatal("replaced timing points not picked up")
+	}
+
+	timing.TimingPoints[len(timing.TimingPoints)-1].BeatLength = 750
+	timing.Reindex()
+
+	if timing.TimingPointAt(4000).BeatLength != 750 {
+		t.Fatal("changed timing point not picked up after reindexing")
+	}
+}
+
+func TestTimingPointLookupsEmpty(t *testing.T) {
+	timing := osu_parser.TimingPointSection{}
+
+	if timing.BeatLengthAt(0) != 1000 || timing.SliderVelocityAt(0) != 1 || timing.IsKiaiAt(0) || timing.ControlPointAt(0).Volume != 100 {
+		t.Fail()
+	}
+}
+
+func TestTimingPointLookupsConcurrent(t *testing.T) {
+	timing := osu_parser.TimingPointSection{
+		TimingPoints: []osu_parser.TimingPoint{{Offset: 0, BeatLength: 500}, {Offset: 1000, BeatLength: 250}},
+	}
+
+	//Never reindexed, every lookup sorts its own copy
+	var wait sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wait.Add(1)
+
+		go func() {
+			defer wait.Done()
+
+			for time := 0.0; time < 2000; time += 10 {
+				expected := 250.0
+
+				if time < 1000 {
+					expected = 500
+				}
+
+				if timing.BeatLengthAt(time) != expected {
+					t.Error("wrong beat length")
+					return
+				}
+			}
+		}()
+	}
+
+	wait.Wait()
+}